@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitArgs splits a line of input into arguments on whitespace like a shell
+// does, so an argument may contain spaces when quoted: `greet "hello world"`
+// gives ["greet", "hello world"]. Single quotes keep everything up to the closing
+// quote literally, inside double quotes and unquoted a backslash escapes the next
+// character. An unterminated quote or trailing backslash is an error
+func SplitArgs(line string) ([]string, error) {
+	args := []string{}
+
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, line)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash in %q", line)
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
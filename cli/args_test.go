@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected []string
+	}{
+		{`greet bob`, []string{"greet", "bob"}},
+		{`  greet   bob  `, []string{"greet", "bob"}},
+		{`greet "hello world"`, []string{"greet", "hello world"}},
+		{`greet 'hello world'`, []string{"greet", "hello world"}},
+		{`greet --name="two  words"`, []string{"greet", "--name=two  words"}},
+		{`greet hello\ world`, []string{"greet", "hello world"}},
+		{`greet "say \"hi\""`, []string{"greet", `say "hi"`}},
+		{`greet 'no \escape'`, []string{"greet", `no \escape`}},
+		{`greet ""`, []string{"greet", ""}},
+		{``, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			args, err := SplitArgs(tt.line)
+			if err != nil {
+				t.Fatalf("SplitArgs failed: %v", err)
+			}
+
+			if strings.Join(args, "|") != strings.Join(tt.expected, "|") || len(args) != len(tt.expected) {
+				t.Errorf("Expected %q, got: %q", tt.expected, args)
+			}
+		})
+	}
+}
+
+func TestSplitArgs_Errors(t *testing.T) {
+	for _, line := range []string{`greet "hello`, `greet 'hello`, `greet hello\`} {
+		if _, err := SplitArgs(line); err == nil {
+			t.Errorf("Expected error for %q", line)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// EncodeClientContext validates s as a Lambda client context JSON document, e.g.
+// {"custom": {"tenant": "acme"}}, and returns it in the form sent with an
+// invoke request. An empty s yields nil so no client context is sent
+func EncodeClientContext(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var cc lambdacontext.ClientContext
+	if err := json.Unmarshal([]byte(s), &cc); err != nil {
+		return nil, fmt.Errorf("invalid client context: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(s)); err != nil {
+		return nil, fmt.Errorf("invalid client context: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+func TestEncodeClientContext(t *testing.T) {
+	encoded, err := EncodeClientContext(`{
+		"custom": {"tenant": "acme"},
+		"env": {"locale": "en-NZ"}
+	}`)
+	if err != nil {
+		t.Fatalf("EncodeClientContext failed: %v", err)
+	}
+
+	if strings.ContainsAny(string(encoded), "\n\t") {
+		t.Errorf("Expected compacted JSON, got: %s", encoded)
+	}
+
+	// decoded the same way the Lambda runtime does before exposing it to the handler
+	var cc lambdacontext.ClientContext
+	if err := json.Unmarshal(encoded, &cc); err != nil {
+		t.Fatalf("Failed to decode client context: %v", err)
+	}
+	if cc.Custom["tenant"] != "acme" || cc.Env["locale"] != "en-NZ" {
+		t.Errorf("Unexpected client context: %+v", cc)
+	}
+}
+
+func TestEncodeClientContextEmpty(t *testing.T) {
+	encoded, err := EncodeClientContext("")
+	if err != nil || encoded != nil {
+		t.Errorf("Expected nil client context, got: %s, %v", encoded, err)
+	}
+}
+
+func TestEncodeClientContextInvalid(t *testing.T) {
+	for _, s := range []string{`{"custom": `, `{"custom": "not a map"}`, `[]`} {
+		if _, err := EncodeClientContext(s); err == nil || !strings.Contains(err.Error(), "invalid client context") {
+			t.Errorf("Expected invalid client context error for %s, got: %v", s, err)
+		}
+	}
+}
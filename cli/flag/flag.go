@@ -3,34 +3,132 @@ package flag
 import (
 	"errors"
 	"fmt"
+	"strconv"
 )
 
 var ErrHelp = errors.New("flag: help requested")
 
+var ErrVersion = errors.New("flag: version requested")
+
+// ErrBadFlagSyntax is returned for malformed flags such as "---name" or "-=x"
+var ErrBadFlagSyntax = errors.New("bad flag syntax")
+
+// ErrUnknownFlag is returned for flags clctl does not define
+var ErrUnknownFlag = errors.New("flag provided but not valid")
+
+// ErrMissingValue is returned when a flag requiring a value is the last argument
+var ErrMissingValue = errors.New("flag needs an argument")
+
+// ErrInvalidValue is returned when a boolean flag is given a value that is not a boolean
+var ErrInvalidValue = errors.New("invalid flag value")
+
+// ErrConflictingFlags is returned for flags that cannot be used together, such
+// as -payload and -parallel
+var ErrConflictingFlags = errors.New("flags cannot be combined")
+
+// Flags holds the clctl options parsed from the command line
+type Flags struct {
+	// FuncName is the name of the Lambda function to invoke
+	FuncName string
+	// Payload is the path of a file holding a pre-built event payload
+	Payload string
+	// Parallel reads one argument set per line from stdin and invokes them concurrently
+	Parallel bool
+	// Msgpack asks the function for a MessagePack encoded response
+	Msgpack bool
+	// Profile is the AWS shared config profile to load credentials from, such
+	// as an SSO profile. The default credential chain is used when empty
+	Profile string
+	// Args are the arguments after --name to forward to the remote cli
+	Args []string
+}
+
+// Parse parses the leading clctl flags from args. Parsing stops after --name,
+// every argument after its value is forwarded to the remote cli untouched
+func Parse(args []string) (*Flags, error) {
+	flags := &Flags{}
+
+	for len(args) > 0 {
+		name, value, consumed, err := parseFlag(args)
+		if err != nil {
+			return nil, err
+		}
+
+		if consumed == 0 {
+			break
+		}
+
+		args = args[consumed:]
+
+		switch name {
+		case "payload":
+			flags.Payload = value
+		case "parallel":
+			flags.Parallel = value == "true"
+		case "msgpack":
+			flags.Msgpack = value == "true"
+		case "profile":
+			flags.Profile = value
+		case "name":
+			// parallel invocations take their args from stdin, there is no single
+			// event for the payload file to replace
+			if flags.Payload != "" && flags.Parallel {
+				return nil, fmt.Errorf("%w: -payload and -parallel", ErrConflictingFlags)
+			}
+
+			flags.FuncName = value
+			flags.Args = args
+			return flags, nil
+		}
+	}
+
+	if flags.FuncName == "" {
+		return nil, fmt.Errorf("missing required flag: -name")
+	}
+
+	return flags, nil
+}
+
 func ParseFuncName(args []string) (string, bool, error) {
+	name, value, consumed, err := parseFlag(args)
+	if err != nil || consumed == 0 {
+		return "", false, err
+	}
+
+	if name != "name" {
+		return "", false, fmt.Errorf("%w: -%s", ErrUnknownFlag, name)
+	}
+
+	return value, true, nil
+}
+
+// parseFlag parses the flag at args[0] returning its name, value and how many
+// arguments it consumed. consumed is 0 when args[0] is not a flag
+func parseFlag(args []string) (string, string, int, error) {
 	if len(args) == 0 {
-		return "", false, nil
+		return "", "", 0, nil
 	}
 	s := args[0]
 	if len(s) < 2 || s[0] != '-' {
-		return "", false, nil
+		return "", "", 0, nil
 	}
 
 	numMinuses := 1
 	if s[1] == '-' {
 		numMinuses++
 		if len(s) == 2 { // "--" terminates the flags
-			return "", false, nil
+			return "", "", 0, nil
 		}
 	}
 
 	name := s[numMinuses:]
 	if len(name) == 0 || name[0] == '-' || name[0] == '=' {
-		return "", false, fmt.Errorf("bad flag syntax: %s", s)
+		return "", "", 0, fmt.Errorf("%w: %s", ErrBadFlagSyntax, s)
 	}
 
 	// it's a flag. does it have an argument?
 	args = args[1:]
+	consumed := 1
 	hasValue := false
 	value := ""
 	for i := 1; i < len(name); i++ { // equals cannot be first
@@ -43,7 +141,26 @@ func ParseFuncName(args []string) (string, bool, error) {
 	}
 
 	if name == "help" || name == "h" { // special case for nice help message.
-		return "", false, ErrHelp
+		return "", "", 0, ErrHelp
+	}
+
+	if name == "version" {
+		return "", "", 0, ErrVersion
+	}
+
+	if name == "parallel" || name == "msgpack" { // boolean flags, never consume the next argument
+		if !hasValue {
+			return name, "true", consumed, nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", "", 0, fmt.Errorf("%w: invalid boolean value %q for -%s", ErrInvalidValue, value, name)
+		}
+		return name, strconv.FormatBool(b), consumed, nil
+	}
+
+	if name != "name" && name != "payload" && name != "profile" {
+		return "", "", 0, fmt.Errorf("%w: -%s", ErrUnknownFlag, name)
 	}
 
 	// It must have a value, which might be the next argument.
@@ -51,15 +168,12 @@ func ParseFuncName(args []string) (string, bool, error) {
 		// value is the next arg
 		hasValue = true
 		value = args[0]
-	}
-
-	if name != "name" {
-		return "", false, fmt.Errorf("flag provided but not valid: -%s", name)
+		consumed++
 	}
 
 	if !hasValue {
-		return "", false, fmt.Errorf("flag needs an argument: -%s", name)
+		return "", "", 0, fmt.Errorf("%w: -%s", ErrMissingValue, name)
 	}
 
-	return value, true, nil
+	return name, value, consumed, nil
 }
@@ -0,0 +1,115 @@
+package flag
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected error
+		message  string
+	}{
+		{
+			name:     "bad syntax",
+			args:     []string{"---name", "fn"},
+			expected: ErrBadFlagSyntax,
+			message:  "bad flag syntax: ---name",
+		},
+		{
+			name:     "unknown flag",
+			args:     []string{"-region", "eu-west-1", "-name", "fn"},
+			expected: ErrUnknownFlag,
+			message:  "flag provided but not valid: -region",
+		},
+		{
+			name:     "missing value",
+			args:     []string{"-payload"},
+			expected: ErrMissingValue,
+			message:  "flag needs an argument: -payload",
+		},
+		{
+			name:     "invalid boolean",
+			args:     []string{"-parallel=maybe", "-name", "fn"},
+			expected: ErrInvalidValue,
+			message:  `invalid flag value: invalid boolean value "maybe" for -parallel`,
+		},
+		{
+			name:     "payload with parallel",
+			args:     []string{"-payload", "event.json", "-parallel", "-name", "fn"},
+			expected: ErrConflictingFlags,
+			message:  "flags cannot be combined: -payload and -parallel",
+		},
+		{
+			name:     "help",
+			args:     []string{"-h"},
+			expected: ErrHelp,
+			message:  "flag: help requested",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.args)
+
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("Expected %v, got: %v", tt.expected, err)
+			}
+			if err.Error() != tt.message {
+				t.Errorf("Expected %q, got: %q", tt.message, err.Error())
+			}
+		})
+	}
+}
+
+func TestParseFuncName_UnknownFlag(t *testing.T) {
+	_, _, err := ParseFuncName([]string{"-payload", "event.json"})
+
+	if !errors.Is(err, ErrUnknownFlag) {
+		t.Errorf("Expected ErrUnknownFlag, got: %v", err)
+	}
+}
+
+func TestParse_Name(t *testing.T) {
+	flags, err := Parse([]string{"-parallel", "--name", "fn", "greet", "--name", "bob"})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if flags.FuncName != "fn" || !flags.Parallel {
+		t.Errorf("Got: %+v", flags)
+	}
+	if len(flags.Args) != 3 || flags.Args[2] != "bob" {
+		t.Errorf("Expected args after --name to be forwarded, got: %v", flags.Args)
+	}
+}
+
+func TestParse_Msgpack(t *testing.T) {
+	flags, err := Parse([]string{"--msgpack", "--name", "fn", "--msgpack"})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if !flags.Msgpack || flags.Parallel {
+		t.Errorf("Got: %+v", flags)
+	}
+	if len(flags.Args) != 1 || flags.Args[0] != "--msgpack" {
+		t.Errorf("Expected --msgpack after --name to be forwarded, got: %v", flags.Args)
+	}
+}
+
+func TestParse_Profile(t *testing.T) {
+	flags, err := Parse([]string{"--profile=sso-dev", "--name", "fn", "--profile", "remote"})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if flags.Profile != "sso-dev" {
+		t.Errorf("Expected profile sso-dev, got %q", flags.Profile)
+	}
+	if len(flags.Args) != 2 || flags.Args[0] != "--profile" {
+		t.Errorf("Expected --profile after --name to be forwarded, got: %v", flags.Args)
+	}
+}
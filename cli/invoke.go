@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+	"net/rpc"
+	"time"
+
+	"github.com/JayJamieson/cobra-lambda/wrapper"
+	"github.com/aws/aws-lambda-go/lambda/messages"
+)
+
+// DefaultInvokeTimeout is the deadline given to the function for each RPC invocation
+const DefaultInvokeTimeout = 10 * time.Second
+
+// Invoker invokes a Lambda function with an encoded event payload
+type Invoker interface {
+	Invoke(payload []byte) (*wrapper.CobraLambdaOutput, error)
+}
+
+// RPCInvoker invokes a Lambda function started with _LAMBDA_SERVER_PORT through
+// its RPC interface
+type RPCInvoker struct {
+	Client *rpc.Client
+	// ClientContext is sent with every invocation when set, see EncodeClientContext
+	ClientContext []byte
+	// Timeout is the deadline passed to the function, DefaultInvokeTimeout when zero.
+	// The function sees it as the deadline of its context, see wrapper.RemainingTime
+	Timeout time.Duration
+}
+
+func (i *RPCInvoker) Invoke(payload []byte) (*wrapper.CobraLambdaOutput, error) {
+	timeout := i.Timeout
+	if timeout <= 0 {
+		timeout = DefaultInvokeTimeout
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	args := messages.InvokeRequest{
+		Payload:       payload,
+		ClientContext: i.ClientContext,
+		Deadline: messages.InvokeRequest_Timestamp{
+			Seconds: deadline.Unix(),
+			Nanos:   int64(deadline.Nanosecond()),
+		},
+	}
+
+	response := &messages.InvokeResponse{}
+	if err := i.Client.Call("Function.Invoke", args, &response); err != nil {
+		return nil, fmt.Errorf("lambda invocation failed: %w", err)
+	}
+
+	if response.Error != nil {
+		return nil, fmt.Errorf("lambda execution error: %s", response.Error.Message)
+	}
+
+	output, err := wrapper.DecodeResponse(response.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return output, nil
+}
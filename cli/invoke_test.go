@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"net"
+	"net/rpc"
+	"testing"
+	"time"
+
+	"github.com/JayJamieson/cobra-lambda/wrapper"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/spf13/cobra"
+)
+
+func TestRPCInvoker_Deadline(t *testing.T) {
+	var deadline time.Time
+	var ok bool
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			deadline, ok = cmd.Context().Deadline()
+		},
+	}
+
+	server := rpc.NewServer()
+	function := lambda.NewFunction(lambda.NewHandler(wrapper.NewCobrLambdaHandler(cmd)))
+	if err := server.RegisterName("Function", function); err != nil {
+		t.Fatalf("Failed to register function: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go server.Accept(listener)
+
+	client, err := rpc.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial function: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	invoker := &RPCInvoker{Client: client, Timeout: 3 * time.Second}
+
+	before := time.Now()
+	if _, err := invoker.Invoke([]byte(`{"args":[]}`)); err != nil {
+		t.Fatalf("Invoke failed: %v", err)
+	}
+
+	if !ok {
+		t.Fatal("Expected the command context to have a deadline")
+	}
+
+	// the deadline is sent with nanosecond precision
+	if deadline.Before(before.Add(3*time.Second)) || deadline.After(time.Now().Add(3*time.Second)) {
+		t.Errorf("Expected deadline about 3s after invoke, got %v from %v", deadline.Sub(before), before)
+	}
+}
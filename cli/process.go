@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// Process is a started Lambda process monitored for exit, so an early exit is
+// noticed while waiting for its RPC server instead of after a timeout
+type Process struct {
+	Cmd  *exec.Cmd
+	done chan struct{}
+	err  error
+}
+
+// StartProcess starts cmd and waits for it in the background. cmd.Wait must not
+// be called elsewhere, use Done and Err instead
+func StartProcess(cmd *exec.Cmd) (*Process, error) {
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start Lambda process: %w", err)
+	}
+
+	p := &Process{Cmd: cmd, done: make(chan struct{})}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+
+	return p, nil
+}
+
+// Done is closed once the process has exited
+func (p *Process) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns the result of waiting for the process, an *exec.ExitError when it
+// exited non-zero. It is only meaningful once Done is closed
+func (p *Process) Err() error {
+	<-p.done
+	return p.err
+}
+
+// ExitCode returns the exit status to report for err: 0 when nil, the code the
+// process exited with for an *exec.ExitError, possibly wrapped, and 1 otherwise,
+// including for processes killed by a signal
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+
+	return 1
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestStartProcess_ExitCode(t *testing.T) {
+	proc, err := StartProcess(exec.Command("sh", "-c", "exit 3"))
+	if err != nil {
+		t.Fatalf("StartProcess failed: %v", err)
+	}
+
+	select {
+	case <-proc.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Process did not exit")
+	}
+
+	if code := ExitCode(proc.Err()); code != 3 {
+		t.Errorf("Expected exit code 3, got: %d", code)
+	}
+
+	// the code survives wrapping, as done by cldebug when the lambda exits early
+	wrapped := fmt.Errorf("lambda server failed to start: %w", fmt.Errorf("lambda process exited before serving: %w", proc.Err()))
+	if code := ExitCode(wrapped); code != 3 {
+		t.Errorf("Expected exit code 3 from wrapped error, got: %d", code)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if code := ExitCode(nil); code != 0 {
+		t.Errorf("Expected 0 for nil, got: %d", code)
+	}
+	if code := ExitCode(errors.New("connection refused")); code != 1 {
+		t.Errorf("Expected 1 for other errors, got: %d", code)
+	}
+}
+
+func TestStartProcess_StartFailure(t *testing.T) {
+	if _, err := StartProcess(exec.Command("/nonexistent/lambda")); err == nil {
+		t.Error("Expected error starting a missing binary")
+	}
+}
+
+func TestRunner_ShutdownProcessAlreadyExited(t *testing.T) {
+	proc, err := StartProcess(startableProcessGroup("exit 3"))
+	if err != nil {
+		t.Fatalf("StartProcess failed: %v", err)
+	}
+	<-proc.Done()
+
+	exited, err := NewRunner(ModeBinary, false, "8001").ShutdownProcess(proc)
+	if err != nil {
+		t.Fatalf("ShutdownProcess failed: %v", err)
+	}
+	if !exited {
+		t.Error("Expected an exited process to be reported as exited")
+	}
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RoutePrefix marks the first argument of an invocation as the name of the
+// target to send it to, e.g. "@v2 greet bob"
+const RoutePrefix = "@"
+
+// Router sends invocations to one of several named targets, for example two
+// versions of the same Lambda binary being compared side by side
+type Router struct {
+	targets map[string]Invoker
+	// Default is the target used for invocations without a route prefix, when
+	// empty every invocation must name its target
+	Default string
+}
+
+func NewRouter() *Router {
+	return &Router{targets: map[string]Invoker{}}
+}
+
+// Add registers invoker under name, the first target added becomes the default
+func (r *Router) Add(name string, invoker Invoker) error {
+	if name == "" || strings.ContainsAny(name, " \t"+RoutePrefix) {
+		return fmt.Errorf("invalid target name %q", name)
+	}
+
+	if _, ok := r.targets[name]; ok {
+		return fmt.Errorf("duplicate target name %q", name)
+	}
+
+	r.targets[name] = invoker
+	if r.Default == "" {
+		r.Default = name
+	}
+
+	return nil
+}
+
+// Names returns the registered target names in sorted order
+func (r *Router) Names() []string {
+	names := make([]string, 0, len(r.targets))
+	for name := range r.targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Route picks the target for args. When args[0] starts with RoutePrefix it names
+// the target and is removed from the returned args, otherwise Default is used
+func (r *Router) Route(args []string) (string, Invoker, []string, error) {
+	name := r.Default
+
+	if len(args) > 0 && strings.HasPrefix(args[0], RoutePrefix) {
+		name = strings.TrimPrefix(args[0], RoutePrefix)
+		args = args[1:]
+	}
+
+	if name == "" {
+		return "", nil, nil, fmt.Errorf("no target given, prefix the arguments with %s<name>", RoutePrefix)
+	}
+
+	invoker, ok := r.targets[name]
+	if !ok {
+		return "", nil, nil, fmt.Errorf("unknown target %q, expected one of %s", name, strings.Join(r.Names(), ", "))
+	}
+
+	return name, invoker, args, nil
+}
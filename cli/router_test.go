@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JayJamieson/cobra-lambda/wrapper"
+)
+
+// fakeInvoker records payloads and answers with a fixed stdout
+type fakeInvoker struct {
+	stdout   string
+	payloads []string
+}
+
+func (f *fakeInvoker) Invoke(payload []byte) (*wrapper.CobraLambdaOutput, error) {
+	f.payloads = append(f.payloads, string(payload))
+	return &wrapper.CobraLambdaOutput{Stdout: f.stdout}, nil
+}
+
+func TestRouter_Route(t *testing.T) {
+	v1 := &fakeInvoker{stdout: "v1"}
+	v2 := &fakeInvoker{stdout: "v2"}
+
+	router := NewRouter()
+	if err := router.Add("v1", v1); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := router.Add("v2", v2); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	tests := []struct {
+		args         []string
+		expectedName string
+		expectedArgs string
+		expected     *fakeInvoker
+	}{
+		{[]string{"@v2", "greet", "bob"}, "v2", "greet bob", v2},
+		{[]string{"@v1", "greet"}, "v1", "greet", v1},
+		{[]string{"greet", "@v2"}, "v1", "greet @v2", v1},
+		{[]string{}, "v1", "", v1},
+	}
+
+	for _, tt := range tests {
+		name, invoker, args, err := router.Route(tt.args)
+		if err != nil {
+			t.Fatalf("Route(%v) failed: %v", tt.args, err)
+		}
+		if name != tt.expectedName || invoker != tt.expected || strings.Join(args, " ") != tt.expectedArgs {
+			t.Errorf("Route(%v) = %s, %v, expected %s, %s", tt.args, name, args, tt.expectedName, tt.expectedArgs)
+		}
+	}
+}
+
+func TestRouter_RouteErrors(t *testing.T) {
+	router := NewRouter()
+	_ = router.Add("v1", &fakeInvoker{})
+
+	if _, _, _, err := router.Route([]string{"@v3", "greet"}); err == nil || !strings.Contains(err.Error(), `unknown target "v3"`) {
+		t.Errorf("Expected unknown target error, got: %v", err)
+	}
+
+	router.Default = ""
+	if _, _, _, err := router.Route([]string{"greet"}); err == nil || !strings.Contains(err.Error(), "no target given") {
+		t.Errorf("Expected missing target error, got: %v", err)
+	}
+}
+
+func TestRouter_Add(t *testing.T) {
+	router := NewRouter()
+
+	if err := router.Add("a", &fakeInvoker{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := router.Add("a", &fakeInvoker{}); err == nil {
+		t.Error("Expected duplicate name error")
+	}
+	for _, name := range []string{"", "with space", "@a"} {
+		if err := router.Add(name, &fakeInvoker{}); err == nil {
+			t.Errorf("Expected invalid name error for %q", name)
+		}
+	}
+
+	_ = router.Add("b", &fakeInvoker{})
+	if strings.Join(router.Names(), ",") != "a,b" || router.Default != "a" {
+		t.Errorf("Unexpected router state: %v default %s", router.Names(), router.Default)
+	}
+}
+
+func TestRouter_InvokeThroughRoute(t *testing.T) {
+	v2 := &fakeInvoker{stdout: "from v2"}
+	router := NewRouter()
+	_ = router.Add("v1", &fakeInvoker{})
+	_ = router.Add("v2", v2)
+
+	runner := NewRunner(ModeBinary, false, "8001")
+
+	_, invoker, args, err := router.Route([]string{"@v2", "greet"})
+	if err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+
+	payload, err := runner.EncodeEvent(args)
+	if err != nil {
+		t.Fatalf("EncodeEvent failed: %v", err)
+	}
+
+	output, err := invoker.Invoke(payload)
+	if err != nil || output.Stdout != "from v2" {
+		t.Fatalf("Unexpected invoke result: %+v, %v", output, err)
+	}
+	if len(v2.payloads) != 1 || v2.payloads[0] != `{"args":["greet"]}` {
+		t.Errorf("Expected routed payload without the prefix, got: %v", v2.payloads)
+	}
+}
@@ -1,11 +1,16 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 )
 
 type RunMode int
@@ -17,12 +22,37 @@ const (
 	ModeGoRun
 )
 
+// DefaultBuildTimeout is how long the go build step in ModeGoRun may take before it is aborted
+const DefaultBuildTimeout = 2 * time.Minute
+
+// DefaultGracePeriod is how long Shutdown waits for the Lambda to exit after SIGTERM
+const DefaultGracePeriod = 200 * time.Millisecond
+
 type Runner struct {
 	Mode       RunMode
 	Debug      bool
 	ServerPort string
+	// BuildTimeout bounds the go build step in ModeGoRun, zero disables the timeout
+	BuildTimeout time.Duration
+	// GoFlags are passed to go build and go run in ModeGoRun, e.g. -race or -tags=foo
+	GoFlags []string
+	// Serializer encodes invocation events, JSON when nil
+	Serializer EventSerializer
+	// Stdin, when set, is read by EncodeEvent and sent as the event's stdin
+	Stdin io.Reader
+	// GracePeriod is how long Shutdown waits after SIGTERM before sending SIGKILL
+	GracePeriod time.Duration
+	// ResponseFormat is sent with each event to negotiate the response format,
+	// e.g. wrapper.ResponseFormatMsgpack, JSON when empty
+	ResponseFormat string
+	// AllowedDirs, when set, restricts CreateCommand to Lambda paths inside one
+	// of these directories, e.g. the module root. Symlinks are resolved first
+	AllowedDirs []string
 }
 
+// ErrPathNotAllowed is returned by CreateCommand for a Lambda path outside AllowedDirs
+var ErrPathNotAllowed = errors.New("lambda path is outside the allowed directories")
+
 type CommandConfig struct {
 	LambdaPath string
 	LambdaArgs []string
@@ -30,9 +60,11 @@ type CommandConfig struct {
 
 func NewRunner(mode RunMode, debug bool, serverPort string) *Runner {
 	return &Runner{
-		Mode:       mode,
-		Debug:      debug,
-		ServerPort: serverPort,
+		Mode:         mode,
+		Debug:        debug,
+		ServerPort:   serverPort,
+		BuildTimeout: DefaultBuildTimeout,
+		GracePeriod:  DefaultGracePeriod,
 	}
 }
 
@@ -81,14 +113,23 @@ func (r *Runner) CreateCommand(config *CommandConfig) (*exec.Cmd, error) {
 		return nil, fmt.Errorf("not found at %s", config.LambdaPath)
 	}
 
+	lambdaPath, err := r.checkAllowed(config.LambdaPath)
+	if err != nil {
+		return nil, err
+	}
+
 	switch r.Mode {
 	case ModeBinary:
-		cmd = exec.Command(config.LambdaPath, config.LambdaArgs...)
+		// absolute when AllowedDirs is set so a bare name is not looked up in PATH
+		cmd = exec.Command(lambdaPath, config.LambdaArgs...)
 
 	case ModeGoRun:
-		// Construct args: go run <lambda-path> [lambda-args...]
-		args := append([]string{"run", config.LambdaPath}, config.LambdaArgs...)
-		cmd = exec.Command("go", args...)
+		// Compile first so a broken module fails fast instead of hanging go run
+		if err := r.Build(config); err != nil {
+			return nil, err
+		}
+
+		cmd = exec.Command("go", r.goRunArgs(config)...)
 	default:
 		return nil, fmt.Errorf("unknown run mode: %d", r.Mode)
 	}
@@ -103,6 +144,116 @@ func (r *Runner) CreateCommand(config *CommandConfig) (*exec.Cmd, error) {
 	return cmd, nil
 }
 
+// checkAllowed returns the path to run for path, resolved to an absolute path
+// when AllowedDirs is set, failing with ErrPathNotAllowed when it is outside them
+func (r *Runner) checkAllowed(path string) (string, error) {
+	if len(r.AllowedDirs) == 0 {
+		return path, nil
+	}
+
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "", fmt.Errorf("resolving lambda path: %w", err)
+	}
+
+	for _, dir := range r.AllowedDirs {
+		allowed, err := resolvePath(dir)
+		if err != nil {
+			r.Debugf("Skipping allowed dir %s: %v", dir, err)
+			continue
+		}
+
+		rel, err := filepath.Rel(allowed, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrPathNotAllowed, path)
+}
+
+// resolvePath returns the absolute path of path with symlinks resolved
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// Build compiles the Go source at config.LambdaPath, populating the Go build cache
+// so the following go run starts without recompiling. It returns an error if
+// compilation fails or does not finish within BuildTimeout
+func (r *Runner) Build(config *CommandConfig) error {
+	ctx := context.Background()
+	if r.BuildTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.BuildTimeout)
+		defer cancel()
+	}
+
+	cmd := r.buildCommand(ctx, config)
+
+	r.Debugf("Building: %v", cmd.Args)
+
+	output, err := cmd.CombinedOutput()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("go build timed out after %s", r.BuildTimeout)
+	}
+
+	if err != nil {
+		return fmt.Errorf("go build failed: %w\n%s", err, output)
+	}
+
+	return nil
+}
+
+// BuildOnly verifies the Lambda at config.LambdaPath compiles without starting
+// or invoking it. It is only meaningful in ModeGoRun, compiled binaries are
+// rejected as there is nothing to build
+func (r *Runner) BuildOnly(config *CommandConfig) error {
+	if r.Mode != ModeGoRun {
+		return fmt.Errorf("build only requires go run mode")
+	}
+
+	if _, err := os.Stat(config.LambdaPath); os.IsNotExist(err) {
+		return fmt.Errorf("not found at %s", config.LambdaPath)
+	}
+
+	return r.Build(config)
+}
+
+func (r *Runner) buildCommand(ctx context.Context, config *CommandConfig) *exec.Cmd {
+	// Construct args: go build [go-flags...] -o /dev/null <lambda-path>
+	args := append([]string{"build"}, r.GoFlags...)
+	args = append(args, "-o", os.DevNull, config.LambdaPath)
+	return exec.CommandContext(ctx, "go", args...)
+}
+
+// goRunArgs constructs args: run [go-flags...] <lambda-path> [lambda-args...]
+func (r *Runner) goRunArgs(config *CommandConfig) []string {
+	args := append([]string{"run"}, r.GoFlags...)
+	args = append(args, config.LambdaPath)
+	return append(args, config.LambdaArgs...)
+}
+
+// ParseGoFlags splits a space separated list of go command flags such as
+// "-race -tags=integration". Every entry must be a flag so nothing can be
+// mistaken for the lambda path or its arguments, flag values must therefore
+// be attached with '='
+func ParseGoFlags(s string) ([]string, error) {
+	flags := strings.Fields(s)
+
+	for _, f := range flags {
+		if !strings.HasPrefix(f, "-") || f == "-" || f == "--" {
+			return nil, fmt.Errorf("invalid go flag %q: go flags must start with '-' and use -flag=value for values", f)
+		}
+	}
+
+	return flags, nil
+}
+
 func (r *Runner) KillProcessGroup(cmd *exec.Cmd, signal syscall.Signal) error {
 	if cmd.Process == nil {
 		return fmt.Errorf("process not started")
@@ -120,6 +271,60 @@ func (r *Runner) KillProcessGroup(cmd *exec.Cmd, signal syscall.Signal) error {
 	return nil
 }
 
+// Shutdown stops the Lambda process started from cmd the way the Lambda service
+// does, sending SIGTERM to its process group and SIGKILL if it has not exited
+// within GracePeriod. It waits for the process, so cmd.Wait must not be called
+// elsewhere. exited reports whether the process exited before being killed
+func (r *Runner) Shutdown(cmd *exec.Cmd) (exited bool, err error) {
+	if cmd.Process == nil {
+		return false, fmt.Errorf("process not started")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(done)
+	}()
+
+	return r.shutdown(cmd, done)
+}
+
+// ShutdownProcess is Shutdown for a process started with StartProcess
+func (r *Runner) ShutdownProcess(p *Process) (exited bool, err error) {
+	return r.shutdown(p.Cmd, p.Done())
+}
+
+// shutdown signals cmd, using done to learn when it has exited
+func (r *Runner) shutdown(cmd *exec.Cmd, done <-chan struct{}) (exited bool, err error) {
+	select {
+	case <-done:
+		return true, nil
+	default:
+	}
+
+	r.Debugf("Sending SIGTERM to Lambda process...")
+	if err := r.KillProcessGroup(cmd, syscall.SIGTERM); err != nil {
+		r.Debugf("Failed to send SIGTERM: %v", err)
+	}
+
+	timer := time.NewTimer(r.GracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true, nil
+	case <-timer.C:
+	}
+
+	r.Debugf("Lambda process did not exit within %s, sending SIGKILL...", r.GracePeriod)
+	if err := r.KillProcessGroup(cmd, syscall.SIGKILL); err != nil {
+		return false, err
+	}
+
+	<-done
+	return false, nil
+}
+
 func (r *Runner) Debugf(format string, args ...any) {
 	if r.Debug {
 		fmt.Fprintf(os.Stderr, "[DEBUG] "+format+"\n", args...)
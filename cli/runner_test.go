@@ -0,0 +1,325 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func writeLambdaSource(t *testing.T, source string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+		t.Fatalf("Failed to write source: %v", err)
+	}
+	return path
+}
+
+func TestRunner_BuildCommandUsesContext(t *testing.T) {
+	runner := NewRunner(ModeGoRun, false, "8001")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cmd := runner.buildCommand(ctx, &CommandConfig{LambdaPath: "main.go"})
+
+	expected := []string{"go", "build", "-o", os.DevNull, "main.go"}
+	if strings.Join(cmd.Args, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected args %v, got: %v", expected, cmd.Args)
+	}
+
+	// A command bound to a cancelled context must not start
+	if err := cmd.Start(); err == nil {
+		_ = cmd.Wait()
+		t.Error("Expected build command to be bound to the context")
+	}
+}
+
+func TestRunner_BuildTimeout(t *testing.T) {
+	runner := NewRunner(ModeGoRun, false, "8001")
+	runner.BuildTimeout = time.Nanosecond
+
+	path := writeLambdaSource(t, "package main\n\nfunc main() {}\n")
+
+	err := runner.Build(&CommandConfig{LambdaPath: path})
+
+	if err == nil {
+		t.Fatal("Expected timeout error, got nil")
+	}
+	if !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected timeout error, got: %v", err)
+	}
+}
+
+func TestRunner_BuildFailure(t *testing.T) {
+	runner := NewRunner(ModeGoRun, false, "8001")
+
+	path := writeLambdaSource(t, "package main\n\nfunc main() { undefined() }\n")
+
+	err := runner.Build(&CommandConfig{LambdaPath: path})
+
+	if err == nil {
+		t.Fatal("Expected build error, got nil")
+	}
+	if !strings.Contains(err.Error(), "go build failed") {
+		t.Errorf("Expected build failure, got: %v", err)
+	}
+}
+
+func TestRunner_DefaultBuildTimeout(t *testing.T) {
+	runner := NewRunner(ModeGoRun, false, "8001")
+
+	if runner.BuildTimeout != DefaultBuildTimeout {
+		t.Errorf("Expected default build timeout %v, got: %v", DefaultBuildTimeout, runner.BuildTimeout)
+	}
+}
+
+func TestRunner_GoRunArgs(t *testing.T) {
+	config := &CommandConfig{
+		LambdaPath: "main.go",
+		LambdaArgs: []string{"sub", "-race"},
+	}
+
+	tests := []struct {
+		name     string
+		goFlags  []string
+		expected []string
+	}{
+		{
+			name:     "without go flags",
+			expected: []string{"run", "main.go", "sub", "-race"},
+		},
+		{
+			name:     "with go flags",
+			goFlags:  []string{"-race", "-tags=integration"},
+			expected: []string{"run", "-race", "-tags=integration", "main.go", "sub", "-race"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewRunner(ModeGoRun, false, "8001")
+			runner.GoFlags = tt.goFlags
+
+			args := runner.goRunArgs(config)
+
+			if strings.Join(args, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("Expected %v, got: %v", tt.expected, args)
+			}
+		})
+	}
+}
+
+func TestRunner_BuildCommandWithGoFlags(t *testing.T) {
+	runner := NewRunner(ModeGoRun, false, "8001")
+	runner.GoFlags = []string{"-tags=integration"}
+
+	cmd := runner.buildCommand(context.Background(), &CommandConfig{LambdaPath: "main.go"})
+
+	expected := []string{"go", "build", "-tags=integration", "-o", os.DevNull, "main.go"}
+	if strings.Join(cmd.Args, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected args %v, got: %v", expected, cmd.Args)
+	}
+}
+
+func TestParseGoFlags(t *testing.T) {
+	flags, err := ParseGoFlags("  -race   -tags=integration ")
+	if err != nil {
+		t.Fatalf("ParseGoFlags failed: %v", err)
+	}
+	if strings.Join(flags, " ") != "-race -tags=integration" {
+		t.Errorf("Unexpected flags: %v", flags)
+	}
+
+	flags, err = ParseGoFlags("")
+	if err != nil || len(flags) != 0 {
+		t.Errorf("Expected no flags for empty input, got: %v, %v", flags, err)
+	}
+
+	for _, input := range []string{"-tags integration", "main.go", "--"} {
+		if _, err := ParseGoFlags(input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+func TestRunner_BuildOnly(t *testing.T) {
+	runner := NewRunner(ModeGoRun, false, "8001")
+
+	path := writeLambdaSource(t, "package main\n\nfunc main() {}\n")
+
+	if err := runner.BuildOnly(&CommandConfig{LambdaPath: path}); err != nil {
+		t.Fatalf("Expected build to succeed, got: %v", err)
+	}
+
+	broken := writeLambdaSource(t, "package main\n\nfunc main() { undefined() }\n")
+
+	err := runner.BuildOnly(&CommandConfig{LambdaPath: broken})
+	if err == nil || !strings.Contains(err.Error(), "go build failed") {
+		t.Errorf("Expected build failure, got: %v", err)
+	}
+}
+
+func TestRunner_BuildOnlyRequiresGoRun(t *testing.T) {
+	runner := NewRunner(ModeBinary, false, "8001")
+
+	err := runner.BuildOnly(&CommandConfig{LambdaPath: "./lambda-binary"})
+	if err == nil || !strings.Contains(err.Error(), "requires go run mode") {
+		t.Errorf("Expected go run mode error, got: %v", err)
+	}
+}
+
+func TestRunner_BuildOnlyMissingSource(t *testing.T) {
+	runner := NewRunner(ModeGoRun, false, "8001")
+
+	err := runner.BuildOnly(&CommandConfig{LambdaPath: "does-not-exist/main.go"})
+	if err == nil || !strings.Contains(err.Error(), "not found at") {
+		t.Errorf("Expected not found error, got: %v", err)
+	}
+}
+
+// startableProcessGroup returns a command running script with sh in its own
+// process group, as CreateCommand does
+func startableProcessGroup(script string) *exec.Cmd {
+	cmd := exec.Command("sh", "-c", script)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return cmd
+}
+
+// startProcessGroup starts script with sh in its own process group
+func startProcessGroup(t *testing.T, script string) *exec.Cmd {
+	t.Helper()
+
+	cmd := startableProcessGroup(script)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start process: %v", err)
+	}
+
+	return cmd
+}
+
+func TestRunner_ShutdownGraceful(t *testing.T) {
+	runner := NewRunner(ModeBinary, false, "8001")
+	runner.GracePeriod = 5 * time.Second
+
+	cmd := startProcessGroup(t, "sleep 10")
+
+	start := time.Now()
+	exited, err := runner.Shutdown(cmd)
+
+	if err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if !exited {
+		t.Error("Expected the process to exit on SIGTERM")
+	}
+	if elapsed := time.Since(start); elapsed >= runner.GracePeriod {
+		t.Errorf("Expected Shutdown to return once the process exited, took %v", elapsed)
+	}
+}
+
+func TestRunner_ShutdownEscalatesToKill(t *testing.T) {
+	runner := NewRunner(ModeBinary, false, "8001")
+	runner.GracePeriod = 300 * time.Millisecond
+
+	// ignores SIGTERM like a function stuck in its shutdown hook
+	cmd := startProcessGroup(t, `trap "" TERM; sleep 10`)
+	// give the shell time to install the trap
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	exited, err := runner.Shutdown(cmd)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if exited {
+		t.Error("Expected the process to be killed")
+	}
+	if elapsed < runner.GracePeriod {
+		t.Errorf("Expected SIGKILL only after the %v grace period, took %v", runner.GracePeriod, elapsed)
+	}
+	if elapsed > 5*time.Second {
+		t.Errorf("Expected the process to be killed promptly after the grace period, took %v", elapsed)
+	}
+}
+
+func TestRunner_DefaultGracePeriod(t *testing.T) {
+	runner := NewRunner(ModeBinary, false, "8001")
+
+	if runner.GracePeriod != DefaultGracePeriod {
+		t.Errorf("Expected default grace period %v, got: %v", DefaultGracePeriod, runner.GracePeriod)
+	}
+}
+
+func TestRunner_ShutdownNotStarted(t *testing.T) {
+	runner := NewRunner(ModeBinary, false, "8001")
+
+	if _, err := runner.Shutdown(exec.Command("sleep", "1")); err == nil {
+		t.Error("Expected error for a process that was not started")
+	}
+}
+
+func TestRunner_CreateCommandAllowedDirs(t *testing.T) {
+	allowed := t.TempDir()
+	outside := t.TempDir()
+
+	inside := filepath.Join(allowed, "bin", "lambda")
+	if err := os.MkdirAll(filepath.Dir(inside), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{inside, filepath.Join(outside, "lambda")} {
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a symlink inside the allowed dir pointing outside of it
+	link := filepath.Join(allowed, "link")
+	if err := os.Symlink(filepath.Join(outside, "lambda"), link); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := NewRunner(ModeBinary, false, "8001")
+	runner.AllowedDirs = []string{filepath.Join(allowed, "missing"), allowed}
+
+	cmd, err := runner.CreateCommand(&CommandConfig{LambdaPath: inside})
+	if err != nil {
+		t.Fatalf("Expected path inside the allowed dir to be accepted, got %v", err)
+	}
+	if resolved, _ := filepath.EvalSymlinks(inside); cmd.Path != resolved {
+		t.Errorf("Expected the resolved path to be run, got %s", cmd.Path)
+	}
+
+	for _, path := range []string{
+		filepath.Join(outside, "lambda"),
+		filepath.Join(allowed, "..", filepath.Base(outside), "lambda"),
+		link,
+	} {
+		if _, err := runner.CreateCommand(&CommandConfig{LambdaPath: path}); !errors.Is(err, ErrPathNotAllowed) {
+			t.Errorf("Expected ErrPathNotAllowed for %s, got %v", path, err)
+		}
+	}
+}
+
+func TestRunner_CreateCommandWithoutAllowedDirs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lambda")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := NewRunner(ModeBinary, false, "8001")
+
+	if _, err := runner.CreateCommand(&CommandConfig{LambdaPath: path}); err != nil {
+		t.Errorf("Expected any path to be accepted without AllowedDirs, got %v", err)
+	}
+}
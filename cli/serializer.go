@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/JayJamieson/cobra-lambda/wrapper"
+)
+
+// EventSerializer encodes the event sent to the Lambda function over RPC
+type EventSerializer interface {
+	Marshal(event wrapper.CobraLambdaEvent) ([]byte, error)
+}
+
+// JSONSerializer encodes events as JSON, the format the wrapper handlers decode
+type JSONSerializer struct{}
+
+func (JSONSerializer) Marshal(event wrapper.CobraLambdaEvent) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+// EncodeEvent builds the event for args and encodes it with the runner's
+// Serializer, JSON when none is set. When Stdin is set it is read to the end
+// and forwarded as the event's stdin. ResponseFormat is set on the event
+func (r *Runner) EncodeEvent(args []string) ([]byte, error) {
+	serializer := r.Serializer
+	if serializer == nil {
+		serializer = JSONSerializer{}
+	}
+
+	event := wrapper.EventFromArgs(args)
+	event.ResponseFormat = r.ResponseFormat
+
+	if r.Stdin != nil {
+		stdin, err := io.ReadAll(r.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		event.Stdin = string(stdin)
+	}
+
+	return serializer.Marshal(event)
+}
+
+// IsPiped reports whether f is a pipe or file rather than a terminal, used to
+// decide whether to forward the tool's own stdin to the Lambda
+func IsPiped(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
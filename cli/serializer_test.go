@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/JayJamieson/cobra-lambda/wrapper"
+)
+
+// upperSerializer is an alternate encoding used to check the serializer is pluggable
+type upperSerializer struct {
+	events []wrapper.CobraLambdaEvent
+}
+
+func (u *upperSerializer) Marshal(event wrapper.CobraLambdaEvent) ([]byte, error) {
+	u.events = append(u.events, event)
+	return []byte(strings.ToUpper(strings.Join(event.Args, " "))), nil
+}
+
+func TestRunner_EncodeEventDefaultsToJSON(t *testing.T) {
+	runner := NewRunner(ModeBinary, false, "8001")
+
+	payload, err := runner.EncodeEvent([]string{"greet", "--name", "two words"})
+	if err != nil {
+		t.Fatalf("EncodeEvent failed: %v", err)
+	}
+
+	expected := `{"args":["greet","--name","two words"]}`
+	if string(payload) != expected {
+		t.Errorf("Expected %s, got: %s", expected, payload)
+	}
+
+	payload, err = runner.EncodeEvent(nil)
+	if err != nil {
+		t.Fatalf("EncodeEvent failed: %v", err)
+	}
+	if string(payload) != `{"args":[]}` {
+		t.Errorf("Expected empty args list, got: %s", payload)
+	}
+}
+
+func TestRunner_EncodeEventCustomSerializer(t *testing.T) {
+	serializer := &upperSerializer{}
+	runner := NewRunner(ModeBinary, false, "8001")
+	runner.Serializer = serializer
+
+	payload, err := runner.EncodeEvent([]string{"greet", "bob"})
+	if err != nil {
+		t.Fatalf("EncodeEvent failed: %v", err)
+	}
+
+	if string(payload) != "GREET BOB" {
+		t.Errorf("Expected custom encoding, got: %s", payload)
+	}
+	if len(serializer.events) != 1 || strings.Join(serializer.events[0].Args, " ") != "greet bob" {
+		t.Errorf("Expected serializer to receive the event, got: %+v", serializer.events)
+	}
+}
+
+func TestRunner_EncodeEventStdin(t *testing.T) {
+	runner := NewRunner(ModeBinary, false, "8001")
+	runner.Stdin = strings.NewReader("line one\nline two\n")
+
+	payload, err := runner.EncodeEvent([]string{"import"})
+	if err != nil {
+		t.Fatalf("EncodeEvent failed: %v", err)
+	}
+
+	expected := `{"args":["import"],"stdin":"line one\nline two\n"}`
+	if string(payload) != expected {
+		t.Errorf("Expected %s, got: %s", expected, payload)
+	}
+}
+
+func TestRunner_EncodeEventStdinError(t *testing.T) {
+	runner := NewRunner(ModeBinary, false, "8001")
+	runner.Stdin = iotest.ErrReader(errors.New("read failed"))
+
+	if _, err := runner.EncodeEvent([]string{"import"}); err == nil || !strings.Contains(err.Error(), "read failed") {
+		t.Errorf("Expected read error, got: %v", err)
+	}
+}
+
+func TestIsPiped(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	if !IsPiped(r) {
+		t.Error("Expected a pipe to be reported as piped")
+	}
+}
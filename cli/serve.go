@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/JayJamieson/cobra-lambda/wrapper"
+)
+
+// maxEventSize bounds the request body accepted by the HTTP handler
+const maxEventSize = 6 << 20
+
+// HTTPHandler forwards CobraLambdaEvents POSTed as JSON to a Lambda and answers
+// with its CobraLambdaOutput as JSON, for trying a function out with curl or
+// Postman. Malformed events get a 400 response and failed invocations a 502
+// response with the error as {"error": "..."}
+type HTTPHandler struct {
+	Invoker Invoker
+	// Serializer encodes the event for the Lambda, JSON when nil
+	Serializer EventSerializer
+}
+
+func NewHTTPHandler(invoker Invoker, serializer EventSerializer) *HTTPHandler {
+	return &HTTPHandler{Invoker: invoker, Serializer: serializer}
+}
+
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSONError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed, POST an event", r.Method))
+		return
+	}
+
+	event := wrapper.CobraLambdaEvent{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEventSize)).Decode(&event); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid event: %w", err))
+		return
+	}
+
+	if event.Args == nil {
+		event.Args = []string{}
+	}
+
+	serializer := h.Serializer
+	if serializer == nil {
+		serializer = JSONSerializer{}
+	}
+
+	payload, err := serializer.Marshal(event)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("failed to marshal event: %w", err))
+		return
+	}
+
+	output, err := h.Invoker.Invoke(payload)
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, output)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/rpc"
+	"strings"
+	"testing"
+
+	"github.com/JayJamieson/cobra-lambda/wrapper"
+	"github.com/aws/aws-lambda-go/lambda/messages"
+)
+
+// fakeFunction stands in for the RPC server of a Lambda started with
+// _LAMBDA_SERVER_PORT, echoing the arguments of each event
+type fakeFunction struct{}
+
+func (fakeFunction) Invoke(request *messages.InvokeRequest, response *messages.InvokeResponse) error {
+	event := wrapper.CobraLambdaEvent{}
+	if err := json.Unmarshal(request.Payload, &event); err != nil {
+		return err
+	}
+
+	if len(event.Args) > 0 && event.Args[0] == "fail" {
+		response.Error = &messages.InvokeResponse_Error{Message: "command failed"}
+		return nil
+	}
+
+	payload, err := json.Marshal(wrapper.CobraLambdaOutput{Stdout: strings.Join(event.Args, " ")})
+	if err != nil {
+		return err
+	}
+	response.Payload = payload
+	return nil
+}
+
+func newFakeRPCInvoker(t *testing.T) *RPCInvoker {
+	t.Helper()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("Function", fakeFunction{}); err != nil {
+		t.Fatalf("Failed to register fake function: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go server.Accept(listener)
+
+	client, err := rpc.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial fake function: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return &RPCInvoker{Client: client}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	server := httptest.NewServer(NewHTTPHandler(newFakeRPCInvoker(t), nil))
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "invokes the lambda",
+			method:         http.MethodPost,
+			body:           `{"args": ["greet", "--name", "bob"]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"stdout":"greet --name bob"`,
+		},
+		{
+			name:           "invalid event",
+			method:         http.MethodPost,
+			body:           `{"args": "greet"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `invalid event`,
+		},
+		{
+			name:           "execution error",
+			method:         http.MethodPost,
+			body:           `{"args": ["fail"]}`,
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `{"error":"lambda execution error: command failed"}`,
+		},
+		{
+			name:           "wrong method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedBody:   `POST an event`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(tt.method, server.URL, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer response.Body.Close()
+
+			var body bytes.Buffer
+			if _, err := body.ReadFrom(response.Body); err != nil {
+				t.Fatalf("Failed to read response: %v", err)
+			}
+
+			if response.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got: %d", tt.expectedStatus, response.StatusCode)
+			}
+			if response.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("Expected JSON response, got: %s", response.Header.Get("Content-Type"))
+			}
+			if !strings.Contains(body.String(), tt.expectedBody) {
+				t.Errorf("Expected body to contain %s, got: %s", tt.expectedBody, body.String())
+			}
+		})
+	}
+}
+
+// failingInvoker always fails to reach the function
+type failingInvoker struct{}
+
+func (failingInvoker) Invoke(payload []byte) (*wrapper.CobraLambdaOutput, error) {
+	return nil, errors.New("lambda invocation failed: connection refused")
+}
+
+func TestHTTPHandler_InvokeFailure(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"args": []}`))
+
+	NewHTTPHandler(failingInvoker{}, nil).ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusBadGateway {
+		t.Errorf("Expected status 502, got: %d", recorder.Code)
+	}
+	if !strings.Contains(recorder.Body.String(), "connection refused") {
+		t.Errorf("Got: %s", recorder.Body.String())
+	}
+}
@@ -0,0 +1,62 @@
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Version and Commit are populated at build time with
+// -ldflags "-X github.com/JayJamieson/cobra-lambda/cli/version.Version=v1.2.3 -X github.com/JayJamieson/cobra-lambda/cli/version.Commit=abc123"
+var (
+	Version = ""
+	Commit  = ""
+)
+
+// readBuildInfo is swapped out in tests
+var readBuildInfo = debug.ReadBuildInfo
+
+type Info struct {
+	Version   string
+	Commit    string
+	GoVersion string
+}
+
+// Get returns the build information of the running binary. Values set via
+// -ldflags take precedence, otherwise the module build info embedded by the Go
+// toolchain is used
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := readBuildInfo(); ok {
+		if info.Version == "" {
+			info.Version = bi.Main.Version
+		}
+
+		if info.Commit == "" {
+			for _, setting := range bi.Settings {
+				if setting.Key == "vcs.revision" {
+					info.Commit = setting.Value
+					break
+				}
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "(devel)"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+
+	return info
+}
+
+func (i Info) String() string {
+	return fmt.Sprintf("version %s, commit %s, %s", i.Version, i.Commit, i.GoVersion)
+}
@@ -0,0 +1,76 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func withBuildInfo(t *testing.T, bi *debug.BuildInfo, ok bool) {
+	t.Helper()
+
+	original := readBuildInfo
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return bi, ok
+	}
+	t.Cleanup(func() {
+		readBuildInfo = original
+	})
+}
+
+func TestGet_LdflagsTakePrecedence(t *testing.T) {
+	Version, Commit = "v1.2.3", "abc123"
+	t.Cleanup(func() {
+		Version, Commit = "", ""
+	})
+
+	withBuildInfo(t, &debug.BuildInfo{
+		Main:     debug.Module{Version: "v0.0.1"},
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "def456"}},
+	}, true)
+
+	info := Get()
+
+	if info.Version != "v1.2.3" {
+		t.Errorf("Expected version v1.2.3, got: %s", info.Version)
+	}
+	if info.Commit != "abc123" {
+		t.Errorf("Expected commit abc123, got: %s", info.Commit)
+	}
+}
+
+func TestGet_FallbackToBuildInfo(t *testing.T) {
+	withBuildInfo(t, &debug.BuildInfo{
+		Main:     debug.Module{Version: "v0.4.0"},
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "def456"}},
+	}, true)
+
+	info := Get()
+
+	if info.Version != "v0.4.0" {
+		t.Errorf("Expected version v0.4.0, got: %s", info.Version)
+	}
+	if info.Commit != "def456" {
+		t.Errorf("Expected commit def456, got: %s", info.Commit)
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("Expected go version %s, got: %s", runtime.Version(), info.GoVersion)
+	}
+}
+
+func TestGet_NoBuildInfo(t *testing.T) {
+	withBuildInfo(t, nil, false)
+
+	info := Get()
+
+	if info.Version != "(devel)" {
+		t.Errorf("Expected version (devel), got: %s", info.Version)
+	}
+	if info.Commit != "unknown" {
+		t.Errorf("Expected commit unknown, got: %s", info.Commit)
+	}
+	if !strings.Contains(info.String(), runtime.Version()) {
+		t.Errorf("Expected String to contain go version, got: %s", info.String())
+	}
+}
@@ -2,13 +2,19 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/JayJamieson/cobra-lambda/cli/flag"
+	"github.com/JayJamieson/cobra-lambda/cli/version"
 	"github.com/JayJamieson/cobra-lambda/wrapper"
 	lambda "github.com/JayJamieson/go-lambda-invoke"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	awslambda "github.com/aws/aws-sdk-go-v2/service/lambda"
 )
 
 var HelpMessage = `Cobra Lambda
@@ -22,48 +28,159 @@ Usage of cobra-lambda:
 	clctl
 	cobra-lambda --name [function name]
 
+	With a payload file:
+	clctl
+	cobra-lambda --payload [event file] --name [function name]
+
+	In parallel, one argument set per line read from stdin, quote arguments containing spaces:
+	clctl
+	cobra-lambda --parallel --name [function name] < args.txt
+
+	With a MessagePack encoded response, smaller for output JSON escapes heavily:
+	clctl
+	cobra-lambda --msgpack --name [function name]
+
+	With credentials from a named AWS profile, such as an SSO profile after "aws sso login":
+	clctl
+	cobra-lambda --profile [profile name] --name [function name]
+
+	Print version:
+	clctl --version
+
 Arguments after --name will be forwarded to remote cli named [function name]
+When --payload is set the file contents are sent as the event and forwarded arguments are ignored,
+set "responseFormat": "msgpack" in the file instead of --msgpack
+--payload cannot be combined with --parallel, which takes one argument set per line from stdin
 `
 
+// clientFactory creates the Lambda client used to invoke functions with
+// credentials from profile, the default credential chain when empty
+type clientFactory func(ctx context.Context, profile string) (lambda.LambdaClient, error)
+
+// configLoader loads the AWS config, config.LoadDefaultConfig outside of tests
+type configLoader func(ctx context.Context, optFns ...func(*config.LoadOptions) error) (aws.Config, error)
+
+// newClientFactory returns a factory loading the AWS config with load. Profiles
+// using AWS SSO or chaining roles with source_profile are resolved by the SDK,
+// which refreshes SSO access tokens cached by "aws sso login" as they expire
+func newClientFactory(load configLoader) clientFactory {
+	return func(ctx context.Context, profile string) (lambda.LambdaClient, error) {
+		var optFns []func(*config.LoadOptions) error
+		if profile != "" {
+			optFns = append(optFns, config.WithSharedConfigProfile(profile))
+		}
+
+		cfg, err := load(ctx, optFns...)
+		if err != nil {
+			return nil, fmt.Errorf("loading AWS config: %w", err)
+		}
+
+		return awslambda.NewFromConfig(cfg), nil
+	}
+}
+
 func main() {
 	ctx := context.Background()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, newClientFactory(config.LoadDefaultConfig)))
+}
 
-	if len(os.Args[1:]) == 0 {
-		fmt.Println(HelpMessage)
-		os.Exit(2)
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, newClient clientFactory) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stdout, HelpMessage)
+		return 2
 	}
 
-	client, err := lambda.NewDefaultClient(ctx)
+	flags, err := flag.Parse(args)
+
+	if err != nil && errors.Is(err, flag.ErrHelp) {
+		fmt.Fprint(stdout, HelpMessage)
+		return 0
+	}
+
+	if err != nil && errors.Is(err, flag.ErrVersion) {
+		fmt.Fprintf(stdout, "clctl %s\n", version.Get())
+		return 0
+	}
 
 	if err != nil {
-		fmt.Printf("%v", err)
-		os.Exit(1)
+		fmt.Fprintf(stdout, "%v\n", err)
+		return 1
 	}
 
-	funcName, ok, err := flag.ParseFuncName(os.Args[1:])
+	client, err := newClient(ctx, flags.Profile)
 
-	if err != nil && errors.Is(err, flag.ErrHelp) {
-		fmt.Print(HelpMessage)
-		os.Exit(0)
+	if err != nil {
+		fmt.Fprintf(stdout, "%v", err)
+		return 1
+	}
+
+	if flags.Parallel {
+		return runParallel(ctx, client, flags.FuncName, responseFormat(flags), stdin, stdout)
 	}
 
-	if !ok {
-		fmt.Printf("%v\n", err)
-		os.Exit(1)
+	payload, err := buildPayload(flags)
+
+	if err != nil {
+		fmt.Fprintf(stdout, "%v\n", err)
+		return 1
+	}
+
+	output, err := invoke(ctx, client, flags.FuncName, payload)
+
+	if err != nil {
+		fmt.Fprintf(stdout, "%v\n", err)
+		return 1
 	}
 
-	output := &wrapper.CobraLambdaOutput{}
+	fmt.Fprint(stdout, output.Stdout)
 
-	err = lambda.InvokeSync(ctx, client, &lambda.InvokeInput{
+	return 0
+}
+
+// invoke invokes funcName with payload, decoding the response whether it is
+// JSON or MessagePack encoded
+func invoke(ctx context.Context, client lambda.LambdaClient, funcName string, payload any) (*wrapper.CobraLambdaOutput, error) {
+	var response json.RawMessage
+
+	err := lambda.InvokeSync(ctx, client, &lambda.InvokeInput{
 		Name:      funcName,
 		Qualifier: "$LATEST",
-		Payload:   wrapper.CobraLambdaEvent{Args: os.Args[3:]},
-	}, &output)
+		Payload:   payload,
+	}, &response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapper.DecodeResponse(response)
+}
+
+// responseFormat returns the response format to ask for, JSON when empty
+func responseFormat(flags *flag.Flags) string {
+	if flags.Msgpack {
+		return wrapper.ResponseFormatMsgpack
+	}
+	return ""
+}
+
+// buildPayload returns the event to send, read from the --payload file when set
+// or assembled from the forwarded arguments otherwise
+func buildPayload(flags *flag.Flags) (any, error) {
+	if flags.Payload == "" {
+		event := wrapper.EventFromArgs(flags.Args)
+		event.ResponseFormat = responseFormat(flags)
+		return event, nil
+	}
+
+	data, err := os.ReadFile(flags.Payload)
 
 	if err != nil {
-		fmt.Printf("%v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("reading payload file: %w", err)
+	}
+
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("payload file %s does not contain valid JSON", flags.Payload)
 	}
 
-	fmt.Print(output.Stdout)
+	return json.RawMessage(data), nil
 }
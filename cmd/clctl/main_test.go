@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/JayJamieson/cobra-lambda/wrapper"
+	lambda "github.com/JayJamieson/go-lambda-invoke"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	awslambda "github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+// fakeClient records invocations and echoes a fixed stdout back, or the result
+// of respond when set
+type fakeClient struct {
+	mu       sync.Mutex
+	payloads [][]byte
+	names    []string
+	stdout   string
+	respond  func(payload []byte) (string, error)
+}
+
+func (f *fakeClient) Invoke(ctx context.Context, params *awslambda.InvokeInput, optFns ...func(*awslambda.Options)) (*awslambda.InvokeOutput, error) {
+	f.mu.Lock()
+	f.payloads = append(f.payloads, params.Payload)
+	f.names = append(f.names, *params.FunctionName)
+	f.mu.Unlock()
+
+	stdout := f.stdout
+	if f.respond != nil {
+		var err error
+		if stdout, err = f.respond(params.Payload); err != nil {
+			return nil, err
+		}
+	}
+
+	payload, _ := json.Marshal(map[string]string{"stdout": stdout})
+	return &awslambda.InvokeOutput{Payload: payload}, nil
+}
+
+func (f *fakeClient) factory() clientFactory {
+	return func(ctx context.Context, profile string) (lambda.LambdaClient, error) {
+		return f, nil
+	}
+}
+
+func TestRun_ForwardsArgs(t *testing.T) {
+	client := &fakeClient{stdout: "hello\n"}
+	stdout := &bytes.Buffer{}
+
+	code := run(context.Background(), []string{"--name", "my-func", "sub", "--flag", "value"}, nil, stdout, client.factory())
+
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stdout.String())
+	}
+	if stdout.String() != "hello\n" {
+		t.Errorf("Expected 'hello\\n', got: %q", stdout.String())
+	}
+	if client.names[0] != "my-func" {
+		t.Errorf("Expected function my-func, got: %s", client.names[0])
+	}
+	if string(client.payloads[0]) != `{"args":["sub","--flag","value"]}` {
+		t.Errorf("Unexpected payload: %s", client.payloads[0])
+	}
+}
+
+func TestRun_PayloadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(path, []byte(`{"args": ["from", "file"], "extra": true}`), 0o600); err != nil {
+		t.Fatalf("Failed to write payload: %v", err)
+	}
+
+	client := &fakeClient{}
+	stdout := &bytes.Buffer{}
+
+	code := run(context.Background(), []string{"--payload", path, "--name", "my-func", "ignored"}, nil, stdout, client.factory())
+
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stdout.String())
+	}
+	if string(client.payloads[0]) != `{"args":["from","file"],"extra":true}` {
+		t.Errorf("Expected payload file contents to be sent, got: %s", client.payloads[0])
+	}
+}
+
+func TestRun_MalformedPayloadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(path, []byte(`{"args": [`), 0o600); err != nil {
+		t.Fatalf("Failed to write payload: %v", err)
+	}
+
+	client := &fakeClient{}
+	stdout := &bytes.Buffer{}
+
+	code := run(context.Background(), []string{"--payload", path, "--name", "my-func"}, nil, stdout, client.factory())
+
+	if code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "does not contain valid JSON") {
+		t.Errorf("Expected invalid JSON error, got: %s", stdout.String())
+	}
+	if len(client.payloads) != 0 {
+		t.Error("Expected no invocation for malformed payload")
+	}
+}
+
+func TestRun_MissingPayloadFile(t *testing.T) {
+	stdout := &bytes.Buffer{}
+
+	code := run(context.Background(), []string{"--payload", filepath.Join(t.TempDir(), "missing.json"), "--name", "my-func"}, nil, stdout, (&fakeClient{}).factory())
+
+	if code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "reading payload file") {
+		t.Errorf("Expected read error, got: %s", stdout.String())
+	}
+}
+
+func TestRun_ForwardsArgsWithSpaces(t *testing.T) {
+	client := &fakeClient{}
+	stdout := &bytes.Buffer{}
+
+	args := []string{"--name", "my-func", "greet", "hello world", "--title", "  padded  ", "tab\there"}
+	code := run(context.Background(), args, nil, stdout, client.factory())
+
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stdout.String())
+	}
+
+	event := wrapper.CobraLambdaEvent{}
+	if err := json.Unmarshal(client.payloads[0], &event); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+
+	expected := []string{"greet", "hello world", "--title", "  padded  ", "tab\there"}
+	if len(event.Args) != len(expected) {
+		t.Fatalf("Expected %q, got: %q", expected, event.Args)
+	}
+	for i := range expected {
+		if event.Args[i] != expected[i] {
+			t.Errorf("Expected arg %d to be %q, got: %q", i, expected[i], event.Args[i])
+		}
+	}
+}
+
+// msgpackClient answers every invocation with stdout MessagePack encoded when
+// the event asks for it, JSON otherwise
+type msgpackClient struct {
+	stdout string
+}
+
+func (m *msgpackClient) Invoke(ctx context.Context, params *awslambda.InvokeInput, optFns ...func(*awslambda.Options)) (*awslambda.InvokeOutput, error) {
+	event := wrapper.CobraLambdaEvent{}
+	if err := json.Unmarshal(params.Payload, &event); err != nil {
+		return nil, err
+	}
+
+	var response any = &wrapper.CobraLambdaOutput{Stdout: m.stdout}
+	if event.ResponseFormat == wrapper.ResponseFormatMsgpack {
+		encoded, err := wrapper.EncodeResponse(&wrapper.CobraLambdaOutput{Stdout: "msgpack:" + m.stdout}, event.ResponseFormat)
+		if err != nil {
+			return nil, err
+		}
+		response = encoded
+	}
+
+	payload, _ := json.Marshal(response)
+	return &awslambda.InvokeOutput{Payload: payload}, nil
+}
+
+func TestRun_Msgpack(t *testing.T) {
+	client := &msgpackClient{stdout: "hello\n"}
+	factory := func(ctx context.Context, profile string) (lambda.LambdaClient, error) { return client, nil }
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--msgpack", "--name", "my-func"}, want: "msgpack:hello\n"},
+		{args: []string{"--name", "my-func"}, want: "hello\n"},
+		{args: []string{"--msgpack", "--parallel", "--name", "my-func"}, want: "[0] msgpack:hello\n"},
+	} {
+		stdout := &bytes.Buffer{}
+
+		code := run(context.Background(), tt.args, strings.NewReader("greet\n"), stdout, factory)
+		if code != 0 {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stdout.String())
+		}
+
+		if stdout.String() != tt.want {
+			t.Errorf("%v: expected %q, got %q", tt.args, tt.want, stdout.String())
+		}
+	}
+}
+
+func TestNewClientFactory_Profile(t *testing.T) {
+	var loaded config.LoadOptions
+	calls := 0
+	load := func(ctx context.Context, optFns ...func(*config.LoadOptions) error) (aws.Config, error) {
+		calls++
+		loaded = config.LoadOptions{}
+		for _, fn := range optFns {
+			if err := fn(&loaded); err != nil {
+				return aws.Config{}, err
+			}
+		}
+		return aws.Config{Region: "us-east-1"}, nil
+	}
+
+	newClient := newClientFactory(load)
+
+	if _, err := newClient(context.Background(), "sso-dev"); err != nil {
+		t.Fatalf("Creating client failed: %v", err)
+	}
+	if loaded.SharedConfigProfile != "sso-dev" {
+		t.Errorf("Expected profile sso-dev to be loaded, got %q", loaded.SharedConfigProfile)
+	}
+
+	// without a profile the default credential chain, including AWS_PROFILE, applies
+	if _, err := newClient(context.Background(), ""); err != nil {
+		t.Fatalf("Creating client failed: %v", err)
+	}
+	if loaded.SharedConfigProfile != "" || calls != 2 {
+		t.Errorf("Expected no profile to be set, got %q", loaded.SharedConfigProfile)
+	}
+}
+
+func TestNewClientFactory_LoadError(t *testing.T) {
+	load := func(ctx context.Context, optFns ...func(*config.LoadOptions) error) (aws.Config, error) {
+		return aws.Config{}, errors.New("failed to refresh cached SSO token")
+	}
+
+	stdout := &bytes.Buffer{}
+	code := run(context.Background(), []string{"--profile", "sso-dev", "--name", "my-func"}, nil, stdout, newClientFactory(load))
+
+	if code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "loading AWS config: failed to refresh cached SSO token") {
+		t.Errorf("Got: %s", stdout.String())
+	}
+}
+
+func TestRun_Profile(t *testing.T) {
+	var profile string
+	client := &fakeClient{stdout: "ok"}
+	newClient := func(ctx context.Context, p string) (lambda.LambdaClient, error) {
+		profile = p
+		return client, nil
+	}
+
+	code := run(context.Background(), []string{"--profile", "sso-dev", "--name", "my-func"}, nil, &bytes.Buffer{}, newClient)
+
+	if code != 0 || profile != "sso-dev" {
+		t.Errorf("Expected profile sso-dev to be passed to the client factory, got %q (exit %d)", profile, code)
+	}
+}
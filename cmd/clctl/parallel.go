@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/JayJamieson/cobra-lambda/cli"
+	"github.com/JayJamieson/cobra-lambda/wrapper"
+	lambda "github.com/JayJamieson/go-lambda-invoke"
+)
+
+// parallelLimit bounds the number of in-flight invocations in --parallel mode
+const parallelLimit = 8
+
+type parallelResult struct {
+	output *wrapper.CobraLambdaOutput
+	err    error
+}
+
+// runParallel invokes funcName once per non-empty line read from stdin, splitting
+// each line into arguments on whitespace with cli.SplitArgs, so arguments
+// containing spaces can be quoted. Results are printed in input order with
+// each output line prefixed by the index of its input line. Failed invocations are
+// reported per line and make the exit code non-zero
+func runParallel(ctx context.Context, client lambda.LambdaClient, funcName, responseFormat string, stdin io.Reader, stdout io.Writer) int {
+	var argSets [][]string
+
+	scanner := bufio.NewScanner(stdin)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		args, err := cli.SplitArgs(line)
+		if err != nil {
+			fmt.Fprintf(stdout, "reading stdin: line %d: %v\n", n, err)
+			return 1
+		}
+		argSets = append(argSets, args)
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(stdout, "reading stdin: %v\n", err)
+		return 1
+	}
+
+	results := make([]parallelResult, len(argSets))
+	sem := make(chan struct{}, parallelLimit)
+	var wg sync.WaitGroup
+
+	for i, args := range argSets {
+		wg.Add(1)
+		go func(i int, args []string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			event := wrapper.EventFromArgs(args)
+			event.ResponseFormat = responseFormat
+
+			output, err := invoke(ctx, client, funcName, event)
+			results[i] = parallelResult{output: output, err: err}
+		}(i, args)
+	}
+
+	wg.Wait()
+
+	code := 0
+	for i, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(stdout, "[%d] error: %v\n", i, result.err)
+			code = 1
+			continue
+		}
+
+		for _, line := range strings.SplitAfter(result.output.Stdout, "\n") {
+			if line == "" {
+				continue
+			}
+			fmt.Fprintf(stdout, "[%d] %s", i, line)
+			if !strings.HasSuffix(line, "\n") {
+				fmt.Fprintln(stdout)
+			}
+		}
+	}
+
+	return code
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/JayJamieson/cobra-lambda/wrapper"
+)
+
+func TestRun_Parallel(t *testing.T) {
+	client := &fakeClient{
+		respond: func(payload []byte) (string, error) {
+			event := wrapper.CobraLambdaEvent{}
+			if err := json.Unmarshal(payload, &event); err != nil {
+				return "", err
+			}
+			if len(event.Args) > 0 && event.Args[0] == "fail" {
+				return "", errors.New("boom")
+			}
+			return strings.Join(event.Args, " ") + "\n", nil
+		},
+	}
+
+	stdin := strings.NewReader("greet alice\n\nfail now\ngreet bob\n")
+	stdout := &bytes.Buffer{}
+
+	code := run(context.Background(), []string{"--parallel", "--name", "my-func"}, stdin, stdout, client.factory())
+
+	if code != 1 {
+		t.Errorf("Expected exit code 1 for partial failure, got %d", code)
+	}
+
+	if len(client.payloads) != 3 {
+		t.Fatalf("Expected 3 invocations, got %d", len(client.payloads))
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 result lines, got: %q", stdout.String())
+	}
+	if lines[0] != "[0] greet alice" {
+		t.Errorf("Unexpected first line: %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "[1] error:") || !strings.Contains(lines[1], "boom") {
+		t.Errorf("Expected per-line error, got: %q", lines[1])
+	}
+	if lines[2] != "[2] greet bob" {
+		t.Errorf("Unexpected third line: %q", lines[2])
+	}
+}
+
+func TestRun_ParallelAllSucceed(t *testing.T) {
+	client := &fakeClient{stdout: "line one\nline two"}
+	stdout := &bytes.Buffer{}
+
+	code := run(context.Background(), []string{"--parallel", "--name", "my-func"}, strings.NewReader("a\nb\n"), stdout, client.factory())
+
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stdout.String())
+	}
+
+	expected := "[0] line one\n[0] line two\n[1] line one\n[1] line two\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got: %q", expected, stdout.String())
+	}
+}
+
+func TestRun_ParallelQuotedArgs(t *testing.T) {
+	client := &fakeClient{
+		respond: func(payload []byte) (string, error) {
+			event := wrapper.CobraLambdaEvent{}
+			if err := json.Unmarshal(payload, &event); err != nil {
+				return "", err
+			}
+			return strings.Join(event.Args, "|") + "\n", nil
+		},
+	}
+
+	stdin := strings.NewReader("greet \"hello world\"\ngreet 'a  b' c\n")
+	stdout := &bytes.Buffer{}
+
+	code := run(context.Background(), []string{"--parallel", "--name", "my-func"}, stdin, stdout, client.factory())
+
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stdout.String())
+	}
+	if stdout.String() != "[0] greet|hello world\n[1] greet|a  b|c\n" {
+		t.Errorf("Got: %q", stdout.String())
+	}
+}
+
+func TestRun_ParallelWithPayload(t *testing.T) {
+	client := &fakeClient{}
+	stdout := &bytes.Buffer{}
+
+	code := run(context.Background(), []string{"--payload", "event.json", "--parallel", "--name", "my-func"}, strings.NewReader("greet alice\n"), stdout, client.factory())
+
+	if code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if len(client.payloads) != 0 {
+		t.Errorf("Expected no invocations, got %d", len(client.payloads))
+	}
+	if !strings.Contains(stdout.String(), "-payload and -parallel") {
+		t.Errorf("Expected the conflicting flags to be reported, got: %q", stdout.String())
+	}
+}
+
+func TestRun_ParallelUnterminatedQuote(t *testing.T) {
+	client := &fakeClient{}
+	stdout := &bytes.Buffer{}
+
+	code := run(context.Background(), []string{"--parallel", "--name", "my-func"}, strings.NewReader("greet ok\ngreet \"oops\n"), stdout, client.factory())
+
+	if code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if len(client.payloads) != 0 {
+		t.Errorf("Expected no invocations for malformed input, got %d", len(client.payloads))
+	}
+	if !strings.Contains(stdout.String(), "line 2") {
+		t.Errorf("Expected the malformed line to be reported, got: %q", stdout.String())
+	}
+}
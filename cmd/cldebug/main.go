@@ -1,18 +1,13 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
-	"net"
-	"net/rpc"
 	"os"
-	"syscall"
-	"time"
 
 	"github.com/JayJamieson/cobra-lambda/cli"
+	"github.com/JayJamieson/cobra-lambda/cli/version"
 	"github.com/JayJamieson/cobra-lambda/wrapper"
-	"github.com/aws/aws-lambda-go/lambda/messages"
 )
 
 const (
@@ -25,6 +20,22 @@ Runs a Go Lambda function locally over RPC.
 Flags:
   --debug         Enable debug logging
   --go-run        Use 'go run' instead of compiled binary (requires '--' separator)
+  --build-timeout Maximum time the go build step may take with --go-run (default 2m)
+  --timeout       Deadline given to the lambda for each invocation, seen by commands
+                  as the deadline of cmd.Context() (default 10s)
+  --grace         How long to wait for the lambda to exit after SIGTERM before
+                  sending SIGKILL (default 200ms)
+  --go-flags      Flags passed to go build/run with --go-run, e.g. "-race -tags=foo"
+  --build-only    With --go-run, only check that the lambda compiles and exit
+  --msgpack       Ask the lambda for a MessagePack encoded response
+  --client-context Client context JSON sent with the invoke, e.g. '{"custom":{"tenant":"acme"}}'
+  --target        Start a lambda as name=path for an interactive session, repeat to
+                  start several. Lines read from stdin are invoked on the first
+                  target, or on another when prefixed with @name
+  --serve         Serve HTTP on the address, e.g. :9000, forwarding each POSTed
+                  event to the lambda and answering with its output as JSON
+  --allow-dir     Only start lambdas inside the directory, repeat to allow several
+  --version       Print version information and exit
 
 Arguments:
   lambda-path     Path to the compiled Lambda binary or source file
@@ -37,24 +48,62 @@ Examples:
   # Run with go run
   rpc --go-run cmd/lambda/main.go arg1 arg2
 
+  # Run with go run and go flags
+  rpc --go-run --go-flags "-race -tags=integration" cmd/lambda/main.go arg1
+
+  # Check the lambda builds without invoking it, e.g. in CI
+  rpc --go-run --build-only cmd/lambda/main.go
+
+  # Compare two versions, one invocation per line of stdin
+  rpc --target v1=./lambda-v1 --target v2=./lambda-v2
+  > @v2 greet --name bob
+
+  # Forward piped input as the command's stdin
+  echo data | rpc ./lambda-binary import
+
+  # Invoke from curl or Postman
+  rpc --serve :9000 ./lambda-binary
+  curl -d '{"args":["greet","--name","bob"]}' localhost:9000
+
   # Debug mode
   rpc --debug ./lambda-binary
 
 The Lambda binary will be started with _LAMBDA_SERVER_PORT=8001 and invoked over RPC.
+With --target each lambda gets the next port, 8001, 8002 and so on.
 `
 )
 
 var (
-	debugFlag = flag.Bool("debug", false, "Enable debug logging")
-	goRunFlag = flag.Bool("go-run", false, "Use 'go run' instead of compiled binary")
+	debugFlag   = flag.Bool("debug", false, "Enable debug logging")
+	goRunFlag   = flag.Bool("go-run", false, "Use 'go run' instead of compiled binary")
+	versionFlag = flag.Bool("version", false, "Print version information and exit")
+
+	buildTimeoutFlag  = flag.Duration("build-timeout", cli.DefaultBuildTimeout, "Maximum time the go build step may take with --go-run")
+	timeoutFlag       = flag.Duration("timeout", cli.DefaultInvokeTimeout, "Deadline given to the lambda for each invocation")
+	graceFlag         = flag.Duration("grace", cli.DefaultGracePeriod, "How long to wait for the lambda to exit after SIGTERM before SIGKILL")
+	goFlagsFlag       = flag.String("go-flags", "", "Flags passed to go build/run with --go-run")
+	buildOnlyFlag     = flag.Bool("build-only", false, "With --go-run, only check that the lambda compiles and exit")
+	clientContextFlag = flag.String("client-context", "", "Client context JSON sent with the invoke")
+	msgpackFlag       = flag.Bool("msgpack", false, "Ask the lambda for a MessagePack encoded response")
+	serveFlag         = flag.String("serve", "", "Serve HTTP on the address, forwarding POSTed events to the lambda")
+
+	targets   targetsFlag
+	allowDirs dirsFlag
 )
 
 func main() {
+	flag.Var(&targets, "target", "Start the lambda at path under name, as name=path, repeat to start several")
+	flag.Var(&allowDirs, "allow-dir", "Only start lambdas inside the directory, repeat to allow several")
 	flag.Usage = func() {
 		fmt.Print(helpMessage)
 	}
 	flag.Parse()
 
+	if *versionFlag {
+		fmt.Printf("cldebug %s\n", version.Get())
+		os.Exit(0)
+	}
+
 	// Determine run mode
 	mode := cli.ModeBinary
 	if *goRunFlag {
@@ -63,124 +112,98 @@ func main() {
 
 	// Create runner
 	runner := cli.NewRunner(mode, *debugFlag, lambdaServerPort)
+	runner.BuildTimeout = *buildTimeoutFlag
+	runner.GracePeriod = *graceFlag
+	runner.AllowedDirs = allowDirs
 
-	// Parse arguments based on mode (use flag.Args() which contains non-flag arguments)
-	config, err := runner.ParseArgs(flag.Args())
+	if *msgpackFlag {
+		runner.ResponseFormat = wrapper.ResponseFormatMsgpack
+	}
+
+	goFlags, err := cli.ParseGoFlags(*goFlagsFlag)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
 		flag.Usage()
 		os.Exit(1)
 	}
+	runner.GoFlags = goFlags
 
-	runner.Debugf("Mode: %v", mode)
-	runner.Debugf("Lambda path: %s", config.LambdaPath)
-	runner.Debugf("Lambda args: %v", config.LambdaArgs)
-
-	// Create the command
-	cmd, err := runner.CreateCommand(config)
+	clientContext, err := cli.EncodeClientContext(*clientContextFlag)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	if err := cmd.Start(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to start Lambda process: %v\n", err)
+	if len(targets) > 0 {
+		os.Exit(runSession(runner, targets, clientContext, os.Stdin, os.Stdout))
+	}
+
+	// Parse arguments based on mode (use flag.Args() which contains non-flag arguments)
+	config, err := runner.ParseArgs(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	runner.Debugf("Lambda process started with PID: %d", cmd.Process.Pid)
+	runner.Debugf("Mode: %v", mode)
+	runner.Debugf("Lambda path: %s", config.LambdaPath)
+	runner.Debugf("Lambda args: %v", config.LambdaArgs)
 
-	// Ensure we kill the process on exit
-	defer func() {
-		if cmd.Process != nil {
-			runner.Debugf("Cleaning up Lambda process...")
-			_ = runner.KillProcessGroup(cmd, syscall.SIGKILL)
-			_ = cmd.Wait()
+	if *buildOnlyFlag {
+		if err := runner.BuildOnly(config); err != nil {
+			fmt.Fprintf(os.Stderr, "Build failed: %v\n", err)
+			os.Exit(1)
 		}
-	}()
 
-	if err := waitForServer(lambdaServerPort, 5*time.Second); err != nil {
-		fmt.Fprintf(os.Stderr, "Lambda server failed to start: %v\n", err)
-		os.Exit(1)
+		fmt.Printf("Build succeeded: %s\n", config.LambdaPath)
+		os.Exit(0)
 	}
 
-	runner.Debugf("Lambda server is ready on port %s", lambdaServerPort)
-
-	client, err := rpc.Dial("tcp", fmt.Sprintf("localhost:%s", lambdaServerPort))
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to connect to Lambda server: %v\n", err)
-		os.Exit(1)
+	if *serveFlag != "" {
+		os.Exit(runServe(runner, config, clientContext, *serveFlag))
 	}
 
-	defer func() {
-		if client != nil {
-			_ = client.Close()
-		}
-	}()
+	// forward piped input, e.g. echo data | rpc ./lambda-binary cmd
+	if cli.IsPiped(os.Stdin) {
+		runner.Stdin = os.Stdin
+	}
 
-	runner.Debugf("Connected to Lambda RPC server")
+	os.Exit(runSingle(runner, config, clientContext))
+}
 
-	argsEvent := wrapper.CobraLambdaEvent{Args: config.LambdaArgs}
-	payload, err := json.Marshal(argsEvent)
+// runSingle starts the Lambda, invokes it once with the configured args and
+// prints its output
+func runSingle(runner *cli.Runner, config *cli.CommandConfig, clientContext []byte) int {
+	p, err := startLambda(runner, config)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to marshal event: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		// surface the exit code of a lambda that exited before serving, e.g. for CI
+		return cli.ExitCode(err)
 	}
 
-	args := messages.InvokeRequest{
-		Payload: payload,
-		Deadline: messages.InvokeRequest_Timestamp{
-			Seconds: time.Now().Unix() + 10,
-		},
-	}
+	// Ensure we kill the process on exit
+	defer p.Close()
 
-	runner.Debugf("Invoking Lambda function...")
-	invokeResponse := &messages.InvokeResponse{}
-	if err := client.Call("Function.Invoke", args, &invokeResponse); err != nil {
-		fmt.Fprintf(os.Stderr, "Lambda invocation failed: %v\n", err)
-		os.Exit(1)
+	payload, err := runner.EncodeEvent(config.LambdaArgs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to marshal event: %v\n", err)
+		return 1
 	}
 
-	// Check for Lambda execution errors
-	if invokeResponse.Error != nil {
-		fmt.Fprintf(os.Stderr, "Lambda execution error: %s\n", invokeResponse.Error.Message)
-		os.Exit(1)
-	}
+	runner.Debugf("Invoking Lambda function...")
+	invoker := &cli.RPCInvoker{Client: p.client, ClientContext: clientContext, Timeout: *timeoutFlag}
 
-	output := &wrapper.CobraLambdaOutput{}
-	if err := json.Unmarshal(invokeResponse.Payload, &output); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to unmarshal response: %v\n", err)
-		os.Exit(1)
+	output, err := invoker.Invoke(payload)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
 	}
 
 	fmt.Print(output.Stdout)
 
-	runner.Debugf("Sending SIGTERM to Lambda process...")
-	if err := runner.KillProcessGroup(cmd, syscall.SIGTERM); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to send SIGTERM: %v\n", err)
-	}
-
-	time.Sleep(200 * time.Millisecond)
-
-	runner.Debugf("Attempting to call Function.Ping after SIGTERM...")
-	pingResponse := &messages.PingResponse{}
+	p.Terminate()
 
-	if err := client.Call("Function.Ping", messages.PingRequest{}, pingResponse); err != nil {
-		runner.Debugf("Function.Ping failed (expected): %v", err)
-	} else {
-		runner.Debugf("Function.Ping succeeded unexpectedly")
-	}
-}
-
-func waitForServer(port string, timeout time.Duration) error {
-	deadline := time.Now().Add(timeout)
-	for time.Now().Before(deadline) {
-		conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%s", port), 100*time.Millisecond)
-		if err == nil {
-			_ = conn.Close()
-			return nil
-		}
-		time.Sleep(50 * time.Millisecond)
-	}
-	return fmt.Errorf("timeout waiting for server on port %s", port)
+	return 0
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/rpc"
+	"os"
+	"time"
+
+	"github.com/JayJamieson/cobra-lambda/cli"
+	"github.com/aws/aws-lambda-go/lambda/messages"
+)
+
+// lambdaProcess is a Lambda function running locally as a child process and the
+// RPC client connected to it
+type lambdaProcess struct {
+	runner *cli.Runner
+	proc   *cli.Process
+	client *rpc.Client
+	// stopped is set once the process has been shut down
+	stopped bool
+}
+
+// startLambda starts the Lambda described by config on the runner's server port
+// and connects to it once its RPC server is accepting connections. When the
+// process exits first the error wraps its *exec.ExitError, see cli.ExitCode
+func startLambda(runner *cli.Runner, config *cli.CommandConfig) (*lambdaProcess, error) {
+	cmd, err := runner.CreateCommand(config)
+	if err != nil {
+		return nil, err
+	}
+
+	proc, err := cli.StartProcess(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &lambdaProcess{runner: runner, proc: proc}
+
+	runner.Debugf("Lambda process started with PID: %d", cmd.Process.Pid)
+
+	if err := waitForServer(runner.ServerPort, 5*time.Second, proc); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("lambda server failed to start: %w", err)
+	}
+
+	runner.Debugf("Lambda server is ready on port %s", runner.ServerPort)
+
+	p.client, err = rpc.Dial("tcp", fmt.Sprintf("localhost:%s", runner.ServerPort))
+	if err != nil {
+		p.Close()
+		return nil, fmt.Errorf("failed to connect to Lambda server: %w", err)
+	}
+
+	runner.Debugf("Connected to Lambda RPC server")
+
+	return p, nil
+}
+
+// Terminate shuts the Lambda process down the way the Lambda service does,
+// SIGTERM followed by SIGKILL after the grace period, then checks whether the
+// function still answers
+func (p *lambdaProcess) Terminate() {
+	p.shutdown()
+
+	p.runner.Debugf("Attempting to call Function.Ping after SIGTERM...")
+	pingResponse := &messages.PingResponse{}
+
+	if err := p.client.Call("Function.Ping", messages.PingRequest{}, pingResponse); err != nil {
+		p.runner.Debugf("Function.Ping failed (expected): %v", err)
+	} else {
+		p.runner.Debugf("Function.Ping succeeded unexpectedly")
+	}
+}
+
+// Close disconnects from the Lambda process and shuts it down unless
+// Terminate already did
+func (p *lambdaProcess) Close() {
+	if p.client != nil {
+		_ = p.client.Close()
+	}
+
+	if p.proc != nil && !p.stopped {
+		p.runner.Debugf("Cleaning up Lambda process...")
+		p.shutdown()
+	}
+}
+
+func (p *lambdaProcess) shutdown() {
+	p.stopped = true
+
+	exited, err := p.runner.ShutdownProcess(p.proc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to stop Lambda process: %v\n", err)
+		return
+	}
+
+	if !exited {
+		p.runner.Debugf("Lambda process was killed after the %s grace period", p.runner.GracePeriod)
+	}
+}
+
+// waitForServer polls port until it accepts connections, giving up when proc
+// exits first
+func waitForServer(port string, timeout time.Duration, proc *cli.Process) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-proc.Done():
+			return fmt.Errorf("lambda process exited before serving: %w", proc.Err())
+		default:
+		}
+
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%s", port), 100*time.Millisecond)
+		if err == nil {
+			_ = conn.Close()
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Errorf("timeout waiting for server on port %s", port)
+}
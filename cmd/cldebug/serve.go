@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/JayJamieson/cobra-lambda/cli"
+)
+
+// runServe starts the Lambda and serves HTTP on addr, forwarding each POSTed
+// event to it until interrupted
+func runServe(runner *cli.Runner, config *cli.CommandConfig, clientContext []byte, addr string) int {
+	p, err := startLambda(runner, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return cli.ExitCode(err)
+	}
+	defer p.Close()
+
+	invoker := &cli.RPCInvoker{Client: p.client, ClientContext: clientContext, Timeout: *timeoutFlag}
+	server := &http.Server{
+		Addr:    addr,
+		Handler: cli.NewHTTPHandler(invoker, runner.Serializer),
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	fmt.Fprintf(os.Stderr, "Serving %s on http://%s, POST events as JSON\n", config.LambdaPath, addr)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-errs:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	case <-signals:
+		_ = server.Close()
+		p.Terminate()
+		return 0
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/JayJamieson/cobra-lambda/cli"
+)
+
+// targetsFlag collects repeated --target name=path flags
+type targetsFlag []string
+
+func (t *targetsFlag) String() string {
+	return strings.Join(*t, ",")
+}
+
+func (t *targetsFlag) Set(value string) error {
+	name, path, ok := strings.Cut(value, "=")
+	if !ok || name == "" || path == "" {
+		return fmt.Errorf("target must be name=path, got %q", value)
+	}
+
+	*t = append(*t, value)
+	return nil
+}
+
+// dirsFlag collects the directories given with each --allow-dir
+type dirsFlag []string
+
+func (d *dirsFlag) String() string {
+	return strings.Join(*d, ",")
+}
+
+func (d *dirsFlag) Set(value string) error {
+	*d = append(*d, value)
+	return nil
+}
+
+// runSession starts a Lambda per target, each on its own port counting up from
+// the base runner's, and invokes the one each line of in is routed to
+func runSession(base *cli.Runner, targets []string, clientContext []byte, in io.Reader, out io.Writer) int {
+	port, err := strconv.Atoi(base.ServerPort)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid server port %q\n", base.ServerPort)
+		return 1
+	}
+
+	router := cli.NewRouter()
+
+	for i, target := range targets {
+		name, path, _ := strings.Cut(target, "=")
+
+		runner := *base
+		runner.ServerPort = strconv.Itoa(port + i)
+
+		config, err := runner.ParseArgs([]string{path})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: target %s: %v\n", name, err)
+			return 1
+		}
+
+		p, err := startLambda(&runner, config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: target %s: %v\n", name, err)
+			return 1
+		}
+		defer p.Close()
+
+		if err := router.Add(name, &cli.RPCInvoker{Client: p.client, ClientContext: clientContext, Timeout: *timeoutFlag}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	fmt.Fprintf(out, "Targets: %s (default %s), prefix a line with %s<name> to route it\n",
+		strings.Join(router.Names(), ", "), router.Default, cli.RoutePrefix)
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		args, err := cli.SplitArgs(scanner.Text())
+		if err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		name, invoker, args, err := router.Route(args)
+		if err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+			continue
+		}
+
+		payload, err := base.EncodeEvent(args)
+		if err != nil {
+			fmt.Fprintf(out, "[%s] error: %v\n", name, err)
+			continue
+		}
+
+		output, err := invoker.Invoke(payload)
+		if err != nil {
+			fmt.Fprintf(out, "[%s] error: %v\n", name, err)
+			continue
+		}
+
+		for _, line := range strings.Split(strings.TrimSuffix(output.Stdout, "\n"), "\n") {
+			fmt.Fprintf(out, "[%s] %s\n", name, line)
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// scaffold describes the files written by init
+type scaffold struct {
+	// Package is the import path of the package declaring the root command
+	Package string
+	// Root is the exported variable in Package holding the root command
+	Root string
+}
+
+// scaffoldFiles maps each generated file to the template it is rendered from
+var scaffoldFiles = []struct {
+	name     string
+	template string
+}{
+	{"main.go", "templates/main.go.tmpl"},
+	{"event.json", "templates/event.json.tmpl"},
+}
+
+func runInit(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, `Usage: cobra-lambda init --package <import path> [flags]
+
+Writes main.go, wiring the root command to wrapper.Start, and event.json, a
+sample event for clctl --payload or the Lambda console.
+
+Flags:
+`)
+		flags.PrintDefaults()
+	}
+
+	pkg := flags.String("package", "", "Import path of the package declaring the root command")
+	root := flags.String("root", "RootCmd", "Exported variable holding the *cobra.Command")
+	dir := flags.String("dir", ".", "Directory to write the files to")
+	force := flags.Bool("force", false, "Overwrite existing files")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	files, err := render(scaffold{Package: *pkg, Root: *root})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if err := writeFiles(*dir, files, *force); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	for _, f := range scaffoldFiles {
+		fmt.Fprintf(stdout, "created %s\n", filepath.Join(*dir, f.name))
+	}
+
+	return 0
+}
+
+// render executes the templates for s, returning the contents by file name.
+// Go files are gofmt'd, which also rejects output that does not parse
+func render(s scaffold) (map[string][]byte, error) {
+	if s.Package == "" {
+		return nil, errors.New("--package is required")
+	}
+	if strings.ContainsAny(s.Package, "\"`\\ ") {
+		return nil, fmt.Errorf("invalid package import path %q", s.Package)
+	}
+	if !token.IsIdentifier(s.Root) || !token.IsExported(s.Root) {
+		return nil, fmt.Errorf("root must be an exported identifier, got %q", s.Root)
+	}
+
+	files := make(map[string][]byte, len(scaffoldFiles))
+
+	for _, f := range scaffoldFiles {
+		tmpl, err := template.ParseFS(templates, f.template)
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, s); err != nil {
+			return nil, fmt.Errorf("rendering %s: %w", f.name, err)
+		}
+
+		content := buf.Bytes()
+		if filepath.Ext(f.name) == ".go" {
+			if content, err = format.Source(content); err != nil {
+				return nil, fmt.Errorf("generated %s is not valid Go: %w", f.name, err)
+			}
+		}
+
+		files[f.name] = content
+	}
+
+	return files, nil
+}
+
+// writeFiles writes files to dir, refusing to replace existing files unless
+// force is set. Nothing is written when any file would be refused
+func writeFiles(dir string, files map[string][]byte, force bool) error {
+	if !force {
+		for _, f := range scaffoldFiles {
+			path := filepath.Join(dir, f.name)
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("%s already exists, use --force to overwrite", path)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for _, f := range scaffoldFiles {
+		if err := os.WriteFile(filepath.Join(dir, f.name), files[f.name], 0o644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestRunInit_Golden(t *testing.T) {
+	dir := t.TempDir()
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := run([]string{"init", "--dir", dir, "--package", "example.com/tool/cmd", "--root", "RootCmd"}, stdout, stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	for _, f := range scaffoldFiles {
+		got, err := os.ReadFile(filepath.Join(dir, f.name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", f.name, err)
+		}
+
+		golden := filepath.Join("testdata", f.name+".golden")
+		if *update {
+			if err := os.WriteFile(golden, got, 0o644); err != nil {
+				t.Fatalf("Failed to update %s: %v", golden, err)
+			}
+		}
+
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", golden, err)
+		}
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s differs from %s, run go test -update\nGot:\n%s", f.name, golden, got)
+		}
+	}
+}
+
+func TestRender_MainParses(t *testing.T) {
+	files, err := render(scaffold{Package: "example.com/tool/cmd", Root: "Root"})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "main.go", files["main.go"], 0)
+	if err != nil {
+		t.Fatalf("Generated main.go does not parse: %v", err)
+	}
+
+	if file.Name.Name != "main" {
+		t.Errorf("Expected package main, got %s", file.Name.Name)
+	}
+
+	var start bool
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == "Start" {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Name == "wrapper" {
+				start = true
+			}
+		}
+		return true
+	})
+
+	if !start {
+		t.Errorf("Expected main.go to call wrapper.Start, got:\n%s", files["main.go"])
+	}
+}
+
+func TestRender_InvalidInput(t *testing.T) {
+	tests := []scaffold{
+		{Package: "", Root: "RootCmd"},
+		{Package: "example.com/\"tool", Root: "RootCmd"},
+		{Package: "example.com/tool", Root: "rootCmd"},
+		{Package: "example.com/tool", Root: "New()"},
+	}
+
+	for _, s := range tests {
+		if _, err := render(s); err == nil {
+			t.Errorf("Expected an error for %+v", s)
+		}
+	}
+}
+
+func TestRunInit_RefusesOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(existing, []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write main.go: %v", err)
+	}
+
+	stderr := &bytes.Buffer{}
+	code := run([]string{"init", "--dir", dir, "--package", "example.com/tool"}, &bytes.Buffer{}, stderr)
+
+	if code != 1 || !strings.Contains(stderr.String(), "already exists") {
+		t.Fatalf("Expected refusal to overwrite, got %d: %s", code, stderr.String())
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "event.json")); !os.IsNotExist(err) {
+		t.Error("Expected no files to be written when one is refused")
+	}
+
+	code = run([]string{"init", "--dir", dir, "--package", "example.com/tool", "--force"}, &bytes.Buffer{}, stderr)
+	if code != 0 {
+		t.Fatalf("Expected --force to overwrite, got %d: %s", code, stderr.String())
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/JayJamieson/cobra-lambda/cli/version"
+)
+
+const helpMessage = `Usage: cobra-lambda <command> [flags]
+
+Developer tooling for running Cobra CLIs on AWS Lambda.
+
+Commands:
+  init        Scaffold a Lambda main.go and sample event for a root command
+  version     Print version information and exit
+
+Run 'cobra-lambda init --help' for the flags of init.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, helpMessage)
+		return 2
+	}
+
+	switch args[0] {
+	case "init":
+		return runInit(args[1:], stdout, stderr)
+	case "version", "--version":
+		fmt.Fprintf(stdout, "cobra-lambda %s\n", version.Get())
+		return 0
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, helpMessage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "Error: unknown command %q\n\n%s", args[0], helpMessage)
+		return 2
+	}
+}
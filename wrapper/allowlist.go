@@ -0,0 +1,180 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ErrCommandNotAllowed is returned when the invoked command is excluded by
+// WithAllowedCommands or WithDeniedCommands
+var ErrCommandNotAllowed = errors.New("wrapper: command not allowed")
+
+// WithAllowedCommands restricts execution to commands whose path below the root
+// matches one of patterns. Patterns use path.Match syntax against the space
+// separated path, so "db *" permits every command under db and "report:*" every
+// command named with the report: prefix. The root itself is matched as "".
+// Commands matching no pattern fail with ErrCommandNotAllowed
+func WithAllowedCommands(patterns ...string) Option {
+	return func(w *CobraLambda) {
+		w.allowedCommands = append(w.allowedCommands, patterns...)
+	}
+}
+
+// WithDeniedCommands rejects commands whose path matches one of patterns with
+// ErrCommandNotAllowed, using the same syntax as WithAllowedCommands. A deny
+// pattern takes precedence over an allow pattern matching the same command
+func WithDeniedCommands(patterns ...string) Option {
+	return func(w *CobraLambda) {
+		w.deniedCommands = append(w.deniedCommands, patterns...)
+	}
+}
+
+// ErrFlagNotAllowed is returned when an invocation sets a flag hidden with WithHiddenFlags
+var ErrFlagNotAllowed = errors.New("wrapper: flag not allowed")
+
+// WithHiddenFlags keeps callers from setting the named flags, e.g. "config" or
+// "debug", so values fixed by the deployment cannot be overridden. The flags are
+// marked hidden throughout the command tree, leaving them out of help and
+// Describe, and invocations setting them by name or shorthand fail with
+// ErrFlagNotAllowed before the command runs. The command still sees the flags'
+// defaults
+func WithHiddenFlags(names []string) Option {
+	return func(w *CobraLambda) {
+		w.hiddenFlags = append(w.hiddenFlags, names...)
+	}
+}
+
+// hideFlags marks the named flags hidden on every command in the tree
+func hideFlags(cmd *cobra.Command, names []string) {
+	for _, name := range names {
+		for _, fs := range []*pflag.FlagSet{cmd.Flags(), cmd.PersistentFlags()} {
+			if f := fs.Lookup(name); f != nil {
+				f.Hidden = true
+			}
+		}
+	}
+
+	for _, sub := range cmd.Commands() {
+		hideFlags(sub, names)
+	}
+}
+
+// checkHiddenFlags fails when args set one of the hidden flags on the command
+// they resolve to. Arguments after "--" are positional and not checked
+func (w *CobraLambda) checkHiddenFlags(args []string) error {
+	if len(w.hiddenFlags) == 0 {
+		return nil
+	}
+
+	target, _, err := w.cmd.Find(args)
+	if err != nil || target == nil {
+		target = w.cmd
+	}
+
+	hidden := func(f *pflag.Flag) bool {
+		return f != nil && slices.Contains(w.hiddenFlags, f.Name)
+	}
+
+	for _, arg := range args {
+		switch {
+		case arg == "--":
+			return nil
+		case strings.HasPrefix(arg, "--"):
+			name, _, _ := strings.Cut(arg[2:], "=")
+			if slices.Contains(w.hiddenFlags, name) {
+				return fmt.Errorf("%w: --%s", ErrFlagNotAllowed, name)
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// shorthands can be combined as in -vc, stop at the first taking a value
+			for _, c := range arg[1:] {
+				f := lookupShorthand(target, string(c))
+				if hidden(f) {
+					return fmt.Errorf("%w: -%c", ErrFlagNotAllowed, c)
+				}
+				if f == nil || f.NoOptDefVal == "" {
+					break
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// lookupShorthand finds the flag with shorthand on cmd, including flags inherited
+// from its parents
+func lookupShorthand(cmd *cobra.Command, shorthand string) *pflag.Flag {
+	if f := cmd.Flags().ShorthandLookup(shorthand); f != nil {
+		return f
+	}
+	return cmd.InheritedFlags().ShorthandLookup(shorthand)
+}
+
+// checkAllowed resolves args to a command and applies the allow and deny lists
+// and the hidden flags
+func (w *CobraLambda) checkAllowed(args []string) error {
+	if err := w.checkHiddenFlags(args); err != nil {
+		return err
+	}
+
+	if len(w.allowedCommands) == 0 && len(w.deniedCommands) == 0 {
+		return nil
+	}
+
+	name := relativePath(w.cmd, args)
+
+	if matchesAny(w.deniedCommands, name) {
+		return fmt.Errorf("%w: %q is denied", ErrCommandNotAllowed, name)
+	}
+
+	if len(w.allowedCommands) > 0 && !matchesAny(w.allowedCommands, name) {
+		return fmt.Errorf("%w: %q is not in the allowlist", ErrCommandNotAllowed, name)
+	}
+
+	return nil
+}
+
+// relativePath returns the path of the command args resolve to without the
+// root name, or "" when they resolve to the root or to no command at all
+func relativePath(root *cobra.Command, args []string) string {
+	target, _, err := root.Find(args)
+	if err != nil || target == nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(target.CommandPath(), root.CommandPath()), " ")
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrTooManyArgs is returned when an invocation has more args than WithMaxArgs allows
+var ErrTooManyArgs = errors.New("wrapper: too many arguments")
+
+// WithMaxArgs rejects invocations with more than n args with ErrTooManyArgs
+// before the command runs, protecting the function from oversized events.
+// The limit applies to the args the command would receive, after an event's
+// Path and Flags are assembled. n <= 0, the default, means no limit
+func WithMaxArgs(n int) Option {
+	return func(w *CobraLambda) {
+		w.maxArgs = n
+	}
+}
+
+func (w *CobraLambda) checkMaxArgs(args []string) error {
+	if w.maxArgs > 0 && len(args) > w.maxArgs {
+		return fmt.Errorf("%w: got %d, the limit is %d", ErrTooManyArgs, len(args), w.maxArgs)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_AllowedCommands(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		args    []string
+		allowed bool
+		path    string
+	}{
+		{
+			name:    "allow group",
+			opts:    []Option{WithAllowedCommands("db *")},
+			args:    []string{"db", "migrate", "--name", "main"},
+			allowed: true,
+			path:    "root db migrate",
+		},
+		{
+			name:    "allow prefix",
+			opts:    []Option{WithAllowedCommands("report:*")},
+			args:    []string{"report:daily"},
+			allowed: true,
+			path:    "root report:daily",
+		},
+		{
+			name:    "not matching allowlist",
+			opts:    []Option{WithAllowedCommands("db *")},
+			args:    []string{"version"},
+			allowed: false,
+		},
+		{
+			name:    "deny overrides allow",
+			opts:    []Option{WithAllowedCommands("db *"), WithDeniedCommands("db drop")},
+			args:    []string{"db", "drop"},
+			allowed: false,
+		},
+		{
+			name:    "deny leaves rest of group",
+			opts:    []Option{WithAllowedCommands("db *"), WithDeniedCommands("db drop")},
+			args:    []string{"db", "migrate"},
+			allowed: true,
+			path:    "root db migrate",
+		},
+		{
+			name:    "deny only",
+			opts:    []Option{WithDeniedCommands("db *")},
+			args:    []string{"version"},
+			allowed: true,
+			path:    "root version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			printPath := func(cmd *cobra.Command, args []string) {
+				cmd.Print(cmd.CommandPath())
+			}
+
+			db := &cobra.Command{Use: "db"}
+			db.PersistentFlags().String("name", "", "Database name")
+			db.AddCommand(
+				&cobra.Command{Use: "migrate", Run: printPath},
+				&cobra.Command{Use: "drop", Run: printPath},
+			)
+
+			root := &cobra.Command{Use: "root"}
+			root.AddCommand(db,
+				&cobra.Command{Use: "report:daily", Run: printPath},
+				&cobra.Command{Use: "version", Run: printPath},
+			)
+
+			wrapper := NewCobraLambdaCLI(context.TODO(), root, tt.opts...)
+			output, err := wrapper.Execute(tt.args)
+
+			if !tt.allowed {
+				if !errors.Is(err, ErrCommandNotAllowed) {
+					t.Fatalf("Expected ErrCommandNotAllowed, got: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if output.Stdout != tt.path {
+				t.Errorf("Expected %q to run. Got: %s", tt.path, output.Stdout)
+			}
+		})
+	}
+}
+
+func TestCobraWrapper_HiddenFlags(t *testing.T) {
+	newCmd := func() (*cobra.Command, *string) {
+		root := &cobra.Command{Use: "root"}
+		config := root.PersistentFlags().StringP("config", "c", "/etc/app.yaml", "Config file")
+		root.PersistentFlags().BoolP("verbose", "v", false, "Verbose output")
+
+		root.AddCommand(&cobra.Command{
+			Use: "migrate",
+			Run: func(cmd *cobra.Command, args []string) {
+				cmd.Printf("config=%s args=%v", *config, args)
+			},
+		})
+		return root, config
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		allowed bool
+	}{
+		{name: "long flag", args: []string{"migrate", "--config", "/tmp/evil.yaml"}},
+		{name: "long flag with value", args: []string{"migrate", "--config=/tmp/evil.yaml"}},
+		{name: "shorthand", args: []string{"migrate", "-c", "/tmp/evil.yaml"}},
+		{name: "combined shorthand", args: []string{"migrate", "-vc", "/tmp/evil.yaml"}},
+		{name: "other flags", args: []string{"migrate", "-v"}, allowed: true},
+		{name: "after terminator", args: []string{"migrate", "--", "--config"}, allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, _ := newCmd()
+			wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithHiddenFlags([]string{"config"}), WithAllowedCommands("migrate"))
+
+			output, err := wrapper.Execute(tt.args)
+
+			if !tt.allowed {
+				if !errors.Is(err, ErrFlagNotAllowed) {
+					t.Fatalf("Expected ErrFlagNotAllowed, got: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if !strings.HasPrefix(output.Stdout, "config=/etc/app.yaml") {
+				t.Errorf("Expected the default config. Got: %s", output.Stdout)
+			}
+		})
+	}
+
+	cmd, _ := newCmd()
+	NewCobraLambdaCLI(context.TODO(), cmd, WithHiddenFlags([]string{"config"}))
+	if !cmd.PersistentFlags().Lookup("config").Hidden {
+		t.Error("Expected the flag to be marked hidden")
+	}
+}
+
+func TestCobraWrapper_WithMaxArgs(t *testing.T) {
+	cmd := &cobra.Command{
+		Use:  "test",
+		Args: cobra.ArbitraryArgs,
+		Run:  func(cmd *cobra.Command, args []string) {},
+	}
+
+	tests := []struct {
+		name  string
+		max   int
+		args  int
+		fails bool
+	}{
+		{name: "unlimited by default", max: 0, args: 10000},
+		{name: "below limit", max: 3, args: 2},
+		{name: "at limit", max: 3, args: 3},
+		{name: "above limit", max: 3, args: 4, fails: true},
+		{name: "far above limit", max: 100, args: 50000, fails: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithMaxArgs(tt.max))
+
+			_, err := wrapper.Execute(make([]string, tt.args))
+
+			if tt.fails && !errors.Is(err, ErrTooManyArgs) {
+				t.Fatalf("Expected ErrTooManyArgs, got %v", err)
+			}
+			if !tt.fails && err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// defaultContentType is the Content-Type of HTTP responses that are not detected as JSON
+	defaultContentType = "text/plain"
+	// binaryContentType is the Content-Type of HTTP responses holding base64 encoded output
+	binaryContentType = "application/octet-stream"
+)
+
+type APIGatewayFunc func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// NewAPIGatewayHandler returns a handler for API Gateway proxy integrations. The
+// request body is decoded as a CobraLambdaEvent and captured output is returned
+// as the response body. GET requests take their args from the query string
+// instead, see ArgsFromQuery. Commands can set response headers with SetHeader.
+// Malformed bodies produce a 400 response and command errors a 500 response.
+// Output base64 encoded by InvalidUTF8Base64 is returned as a base64 encoded
+// body so clients receive the original bytes
+func NewAPIGatewayHandler(cmd *cobra.Command, opts ...Option) APIGatewayFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if cmd == nil {
+			return events.APIGatewayProxyResponse{}, ErrNilCommand
+		}
+
+		lambda := newCobraLambda(ctx, cmd, opts...)
+
+		response := lambda.executeHTTP(ctx, httpRequest{
+			method:          request.HTTPMethod,
+			query:           url.Values(request.MultiValueQueryStringParameters),
+			body:            request.Body,
+			isBase64Encoded: request.IsBase64Encoded,
+		})
+
+		return events.APIGatewayProxyResponse{
+			StatusCode:      response.status,
+			Headers:         response.headers,
+			Body:            response.body,
+			IsBase64Encoded: response.isBase64Encoded,
+		}, nil
+	}
+}
+
+type FunctionURLFunc func(ctx context.Context, request events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error)
+
+// NewFunctionURLHandler is the Lambda Function URL equivalent of NewAPIGatewayHandler
+func NewFunctionURLHandler(cmd *cobra.Command, opts ...Option) FunctionURLFunc {
+	return func(ctx context.Context, request events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+		if cmd == nil {
+			return events.LambdaFunctionURLResponse{}, ErrNilCommand
+		}
+
+		lambda := newCobraLambda(ctx, cmd, opts...)
+
+		// the parsed QueryStringParameters join repeated keys with commas so the
+		// raw query string is used instead
+		response := lambda.executeHTTP(ctx, httpRequest{
+			method:          request.RequestContext.HTTP.Method,
+			rawQuery:        request.RawQueryString,
+			body:            request.Body,
+			isBase64Encoded: request.IsBase64Encoded,
+		})
+
+		return events.LambdaFunctionURLResponse{
+			StatusCode:      response.status,
+			Headers:         response.headers,
+			Body:            response.body,
+			IsBase64Encoded: response.isBase64Encoded,
+		}, nil
+	}
+}
+
+// QueryArgsKey is the query string parameter GET requests to the HTTP adapters
+// pass args in
+const QueryArgsKey = "args"
+
+// ArgsFromQuery returns the args held by the repeated "args" parameter of a
+// query string, in the order they appear, e.g. ["greet", "--name", "Alice"] for
+// ?args=greet&args=--name&args=Alice
+func ArgsFromQuery(query url.Values) []string {
+	return append([]string{}, query[QueryArgsKey]...)
+}
+
+// httpRequest holds the parts of an HTTP adapter request the event is built from
+type httpRequest struct {
+	method string
+	query  url.Values
+	// rawQuery is parsed in place of query when set
+	rawQuery        string
+	body            string
+	isBase64Encoded bool
+}
+
+// httpResponse holds the parts of an HTTP adapter response
+type httpResponse struct {
+	status          int
+	headers         map[string]string
+	body            string
+	isBase64Encoded bool
+}
+
+// event returns the event for the request, taken from the query string for GET
+// requests and decoded from the JSON body otherwise
+func (r httpRequest) event() (*CobraLambdaEvent, error) {
+	if r.method == http.MethodGet {
+		query := r.query
+		if r.rawQuery != "" {
+			var err error
+			if query, err = url.ParseQuery(r.rawQuery); err != nil {
+				return nil, err
+			}
+		}
+
+		event := EventFromArgs(ArgsFromQuery(query))
+		return &event, nil
+	}
+
+	body := []byte(r.body)
+	if r.isBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(r.body)
+		if err != nil {
+			return nil, err
+		}
+		body = decoded
+	}
+
+	return UnmarshalEvent(json.RawMessage(body))
+}
+
+// executeHTTP runs the command for an HTTP request and returns the response
+func (w *CobraLambda) executeHTTP(ctx context.Context, request httpRequest) httpResponse {
+	event, err := request.event()
+	if err != nil {
+		return textResponse(http.StatusBadRequest, err.Error())
+	}
+
+	headers := &responseHeaders{}
+	ctx = context.WithValue(ctx, responseHeadersKey{}, headers)
+
+	w.runPreExecHooks(ctx, event)
+
+	output, err := w.executeWithHooks(ctx, event)
+	if output == nil {
+		return textResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	status := http.StatusOK
+	if err != nil {
+		status = http.StatusInternalServerError
+	}
+
+	if output.Encoding == EncodingBase64 {
+		// the output is not text, the base64 body is decoded by API Gateway
+		return httpResponse{
+			status:          status,
+			headers:         headers.build(binaryContentType),
+			body:            output.Stdout,
+			isBase64Encoded: true,
+		}
+	}
+
+	detect := w.contentTypeDetector
+	if detect == nil {
+		detect = DetectContentType
+	}
+
+	return httpResponse{status: status, headers: headers.build(detect(output.Stdout)), body: output.Stdout}
+}
+
+func textResponse(status int, body string) httpResponse {
+	return httpResponse{status: status, headers: map[string]string{"Content-Type": defaultContentType}, body: body}
+}
+
+// DetectContentType returns "application/json" when out is a JSON object or
+// array and "text/plain" otherwise, including for empty output
+func DetectContentType(out string) string {
+	trimmed := strings.TrimSpace(out)
+	if trimmed == "" {
+		return defaultContentType
+	}
+
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)) {
+		return "application/json"
+	}
+
+	return defaultContentType
+}
+
+// WithContentTypeDetector replaces DetectContentType for choosing the Content-Type
+// of HTTP adapter responses. A Content-Type set by the command with SetHeader wins
+func WithContentTypeDetector(detect func(out string) string) Option {
+	return func(w *CobraLambda) {
+		w.contentTypeDetector = detect
+	}
+}
+
+type responseHeadersKey struct{}
+
+// responseHeaders collects headers set by the command during execution
+type responseHeaders struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// SetHeader sets a response header from within a command, e.g.
+// wrapper.SetHeader(cmd.Context(), "Cache-Control", "no-store"). It is a no-op
+// when the command is not running under an HTTP adapter such as NewAPIGatewayHandler
+func SetHeader(ctx context.Context, key, value string) {
+	headers, ok := ctx.Value(responseHeadersKey{}).(*responseHeaders)
+	if !ok {
+		return
+	}
+
+	headers.mu.Lock()
+	defer headers.mu.Unlock()
+
+	if headers.values == nil {
+		headers.values = map[string]string{}
+	}
+	headers.values[textproto.CanonicalMIMEHeaderKey(key)] = value
+}
+
+// build returns the collected headers, using contentType unless the command set one
+func (h *responseHeaders) build(contentType string) map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	headers := map[string]string{"Content-Type": contentType}
+	for k, v := range h.values {
+		headers[k] = v
+	}
+	return headers
+}
@@ -0,0 +1,321 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/spf13/cobra"
+)
+
+func TestNewAPIGatewayHandler_Headers(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			SetHeader(cmd.Context(), "cache-control", "no-store")
+			SetHeader(cmd.Context(), "X-Args", strings.Join(args, ","))
+			cmd.Println("hello")
+		},
+	}
+
+	handler := NewAPIGatewayHandler(cmd)
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		Body: `{"args": ["a", "b"]}`,
+	})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got: %d", response.StatusCode)
+	}
+	if response.Headers["Cache-Control"] != "no-store" {
+		t.Errorf("Expected Cache-Control header, got: %v", response.Headers)
+	}
+	if response.Headers["X-Args"] != "a,b" {
+		t.Errorf("Expected X-Args header, got: %v", response.Headers)
+	}
+	if response.Headers["Content-Type"] != "text/plain" {
+		t.Errorf("Expected default Content-Type, got: %v", response.Headers)
+	}
+	if response.Body != "hello\n" {
+		t.Errorf("Expected body 'hello\\n', got: %q", response.Body)
+	}
+}
+
+func TestNewAPIGatewayHandler_ContentTypeOverride(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			SetHeader(cmd.Context(), "Content-Type", "application/json")
+			cmd.Print(`{"ok":true}`)
+		},
+	}
+
+	response, err := NewAPIGatewayHandler(cmd)(context.Background(), events.APIGatewayProxyRequest{
+		Body:            base64.StdEncoding.EncodeToString([]byte(`{"args": []}`)),
+		IsBase64Encoded: true,
+	})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if response.Headers["Content-Type"] != "application/json" {
+		t.Errorf("Expected Content-Type override, got: %v", response.Headers)
+	}
+	if response.Body != `{"ok":true}` {
+		t.Errorf("Unexpected body: %q", response.Body)
+	}
+}
+
+func TestNewAPIGatewayHandler_Errors(t *testing.T) {
+	cmd := &cobra.Command{
+		Use:           "test",
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.Println("partial")
+			return errors.New("command failed")
+		},
+	}
+
+	handler := NewAPIGatewayHandler(cmd)
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{Body: `{"args": [`})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if response.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for malformed body, got: %d", response.StatusCode)
+	}
+
+	response, err = handler(context.Background(), events.APIGatewayProxyRequest{Body: `{"args": []}`})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if response.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 for command error, got: %d", response.StatusCode)
+	}
+	if !strings.Contains(response.Body, "partial") {
+		t.Errorf("Expected captured output in body, got: %q", response.Body)
+	}
+}
+
+func TestSetHeader_WithoutAdapter(t *testing.T) {
+	// Must not panic when no header collector is present
+	SetHeader(context.Background(), "X-Test", "value")
+}
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		out      string
+		expected string
+	}{
+		{name: "json object", out: "{\"ok\": true}\n", expected: "application/json"},
+		{name: "json array", out: "[1, 2, 3]", expected: "application/json"},
+		{name: "invalid json", out: "{not json", expected: "text/plain"},
+		{name: "plain text", out: "hello world\n", expected: "text/plain"},
+		{name: "bare json scalar", out: "42", expected: "text/plain"},
+		{name: "empty", out: "", expected: "text/plain"},
+		{name: "whitespace", out: " \n", expected: "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectContentType(tt.out); got != tt.expected {
+				t.Errorf("Expected %q, got: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewAPIGatewayHandler_DetectsJSON(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Println(`{"status": "ok"}`)
+		},
+	}
+
+	response, err := NewAPIGatewayHandler(cmd)(context.Background(), events.APIGatewayProxyRequest{Body: `{"args": []}`})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if response.Headers["Content-Type"] != "application/json" {
+		t.Errorf("Expected application/json, got: %v", response.Headers)
+	}
+}
+
+func TestNewAPIGatewayHandler_ContentTypeDetectorOption(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Println("name: value")
+		},
+	}
+
+	detector := func(out string) string {
+		return "application/yaml"
+	}
+
+	response, err := NewAPIGatewayHandler(cmd, WithContentTypeDetector(detector))(context.Background(), events.APIGatewayProxyRequest{Body: `{}`})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if response.Headers["Content-Type"] != "application/yaml" {
+		t.Errorf("Expected detector Content-Type, got: %v", response.Headers)
+	}
+}
+
+func TestNewAPIGatewayHandler_Base64Output(t *testing.T) {
+	handler := NewAPIGatewayHandler(newInvalidUTF8Command(), WithoutMirror(), WithInvalidUTF8(InvalidUTF8Base64))
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{Body: `{"args": []}`})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if !response.IsBase64Encoded {
+		t.Error("Expected IsBase64Encoded to be set")
+	}
+	if response.Headers["Content-Type"] != "application/octet-stream" {
+		t.Errorf("Expected binary Content-Type, got: %v", response.Headers)
+	}
+	if decoded, _ := base64.StdEncoding.DecodeString(response.Body); string(decoded) != "ok\xff\xfe" {
+		t.Errorf("Expected the original bytes, got: %q", decoded)
+	}
+}
+
+func TestNewFunctionURLHandler(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			SetHeader(cmd.Context(), "X-Test", "yes")
+			cmd.Printf("args: %s", strings.Join(args, " "))
+		},
+	}
+
+	response, err := NewFunctionURLHandler(cmd)(context.Background(), events.LambdaFunctionURLRequest{
+		Body: `{"args": ["a", "b"]}`,
+	})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got: %d", response.StatusCode)
+	}
+	if response.Body != "args: a b" {
+		t.Errorf("Unexpected body: %q", response.Body)
+	}
+	if response.Headers["X-Test"] != "yes" || response.Headers["Content-Type"] != "text/plain" {
+		t.Errorf("Unexpected headers: %v", response.Headers)
+	}
+}
+
+func TestNewFunctionURLHandler_Base64Output(t *testing.T) {
+	handler := NewFunctionURLHandler(newInvalidUTF8Command(), WithoutMirror(), WithInvalidUTF8(InvalidUTF8Base64))
+
+	response, err := handler(context.Background(), events.LambdaFunctionURLRequest{Body: `{"args": []}`})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if !response.IsBase64Encoded {
+		t.Error("Expected IsBase64Encoded to be set")
+	}
+	if decoded, _ := base64.StdEncoding.DecodeString(response.Body); string(decoded) != "ok\xff\xfe" {
+		t.Errorf("Expected the original bytes, got: %q", decoded)
+	}
+}
+
+func TestArgsFromQuery(t *testing.T) {
+	query, err := url.ParseQuery("args=greet&verbose=1&args=--name&args=Alice+Smith&args=%2Dx")
+	if err != nil {
+		t.Fatalf("ParseQuery failed: %v", err)
+	}
+
+	args := ArgsFromQuery(query)
+
+	want := []string{"greet", "--name", "Alice Smith", "-x"}
+	if strings.Join(args, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected %q, got %q", want, args)
+	}
+
+	if args := ArgsFromQuery(url.Values{}); args == nil || len(args) != 0 {
+		t.Errorf("Expected empty args, got %#v", args)
+	}
+}
+
+func TestNewAPIGatewayHandler_GetQueryArgs(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Printf("args: %s", strings.Join(args, ","))
+		},
+	}
+
+	handler := NewAPIGatewayHandler(cmd, WithoutMirror())
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		MultiValueQueryStringParameters: map[string][]string{
+			"args": {"c", "a", "b"},
+		},
+		// GET bodies are ignored
+		Body: `{"args": ["ignored"]}`,
+	})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if response.StatusCode != http.StatusOK || response.Body != "args: c,a,b" {
+		t.Errorf("Unexpected response: %d %q", response.StatusCode, response.Body)
+	}
+
+	// POST bodies still hold the JSON event
+	response, _ = handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:                      http.MethodPost,
+		MultiValueQueryStringParameters: map[string][]string{"args": {"ignored"}},
+		Body:                            `{"args": ["x", "y"]}`,
+	})
+	if response.Body != "args: x,y" {
+		t.Errorf("Unexpected POST response: %q", response.Body)
+	}
+}
+
+func TestNewFunctionURLHandler_GetQueryArgs(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Printf("args: %s", strings.Join(args, "|"))
+		},
+	}
+
+	handler := NewFunctionURLHandler(cmd, WithoutMirror())
+
+	request := events.LambdaFunctionURLRequest{
+		RawQueryString:        "args=a,b&args=c",
+		QueryStringParameters: map[string]string{"args": "a,b,c"},
+	}
+	request.RequestContext.HTTP.Method = http.MethodGet
+
+	response, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if response.StatusCode != http.StatusOK || response.Body != "args: a,b|c" {
+		t.Errorf("Unexpected response: %d %q", response.StatusCode, response.Body)
+	}
+
+	request.RawQueryString = "args=%zz"
+	response, _ = handler(context.Background(), request)
+	if response.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a malformed query string, got %d", response.StatusCode)
+	}
+}
@@ -0,0 +1,53 @@
+package wrapper
+
+import (
+	"context"
+)
+
+// RecordResult holds the outcome of running the command for a single record of a batch event
+type RecordResult struct {
+	ID     string             `json:"id"`
+	Output *CobraLambdaOutput `json:"output,omitempty"`
+	Error  string             `json:"error,omitempty"`
+}
+
+// BatchOutput aggregates the results of every record in a batch event
+type BatchOutput struct {
+	Results []RecordResult `json:"results"`
+}
+
+// executeRecord decodes payload as a CobraLambdaEvent and runs the command for it.
+// Decode and command errors are recorded on the result rather than returned so
+// one bad record does not abort the rest of the batch
+func (w *CobraLambda) executeRecord(ctx context.Context, id string, payload []byte) RecordResult {
+	result := RecordResult{ID: id}
+
+	event, err := UnmarshalEvent(payload)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	return w.executeRecordEvent(withRawEvent(ctx, payload), id, event)
+}
+
+// executeRecordEvent runs the command for an already decoded record event.
+// Flags left over from the previous record are reset by Execute
+func (w *CobraLambda) executeRecordEvent(ctx context.Context, id string, event *CobraLambdaEvent) RecordResult {
+	result := RecordResult{ID: id}
+
+	// redeliveries of a record keep its id
+	if event.IdempotencyKey == "" {
+		event.IdempotencyKey = id
+	}
+
+	w.runPreExecHooks(ctx, event)
+
+	output, err := w.executeWithHooks(ctx, event)
+	result.Output = output
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
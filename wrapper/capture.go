@@ -0,0 +1,166 @@
+package wrapper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// WithLineBufferedCapture holds back output from each of stdout and stderr until
+// a full line has been written, so lines from the two streams are interleaved
+// whole in the captured output instead of being split at arbitrary boundaries
+func WithLineBufferedCapture() Option {
+	return func(w *CobraLambda) {
+		w.lineBufferedCapture = true
+	}
+}
+
+// captureChunkSize is the most read from a capture pipe at once when
+// WithCaptureBufferSize is set
+const captureChunkSize = 32 * 1024
+
+// WithCaptureBufferSize queues up to n chunks of output read from each of the
+// stdout and stderr pipes before they are written to the captured output, so a
+// command producing bursts of output is not held up while earlier output is
+// being processed, e.g. by redaction of a streamed copy
+func WithCaptureBufferSize(n int) Option {
+	return func(w *CobraLambda) {
+		w.captureBufferSize = n
+	}
+}
+
+// drain copies r to dst until r is closed, a line at a time when line buffered
+// capture is enabled
+func (w *CobraLambda) drain(dst io.Writer, r io.Reader) {
+	if w.lineBufferedCapture {
+		lw := &lineWriter{dst: dst}
+		defer lw.Flush()
+		dst = lw
+	}
+
+	if w.captureBufferSize > 0 {
+		copyQueued(dst, r, w.captureBufferSize)
+		return
+	}
+
+	_, _ = io.Copy(dst, r)
+}
+
+// copyQueued copies r to dst through a queue of up to n chunks, reading from r
+// in its own goroutine so reads continue while dst is busy
+func copyQueued(dst io.Writer, r io.Reader, n int) {
+	chunks := make(chan []byte, n)
+
+	go func() {
+		defer close(chunks)
+		for {
+			buf := make([]byte, captureChunkSize)
+			read, err := r.Read(buf)
+			if read > 0 {
+				chunks <- buf[:read]
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for chunk := range chunks {
+		_, _ = dst.Write(chunk)
+	}
+}
+
+// lineWriter forwards only complete lines to dst, keeping a trailing partial line
+// pending until it is completed or flushed. It is used by a single goroutine
+type lineWriter struct {
+	dst     io.Writer
+	pending []byte
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	l.pending = append(l.pending, p...)
+
+	if i := bytes.LastIndexByte(l.pending, '\n'); i >= 0 {
+		_, _ = l.dst.Write(l.pending[:i+1])
+		l.pending = append(l.pending[:0], l.pending[i+1:]...)
+	}
+
+	return len(p), nil
+}
+
+// Flush writes out any pending partial line
+func (l *lineWriter) Flush() {
+	if len(l.pending) > 0 {
+		_, _ = l.dst.Write(l.pending)
+		l.pending = l.pending[:0]
+	}
+}
+
+// ErrCapturePanicked is returned by Execute when a writer receiving captured
+// output panicked, for example a mirror writer whose file was closed
+var ErrCapturePanicked = errors.New("wrapper: writing captured output panicked")
+
+// drainErrors records the first panic recovered while draining the capture
+// pipes, shared by the stdout and stderr drain goroutines
+type drainErrors struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (d *drainErrors) record(r any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: %v", ErrCapturePanicked, r)
+	}
+}
+
+// recoverPanic is deferred by the drain goroutines so a panic is recorded
+// instead of crashing the process
+func (d *drainErrors) recoverPanic() {
+	if r := recover(); r != nil {
+		d.record(r)
+	}
+}
+
+func (d *drainErrors) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+// guard wraps dst so a panic in its Write is recorded and later writes to it
+// are discarded, leaving the pipe draining and other writers unaffected.
+// A nil dst stays nil
+func (d *drainErrors) guard(dst io.Writer) io.Writer {
+	if dst == nil {
+		return nil
+	}
+	return &guardedWriter{dst: dst, errs: d}
+}
+
+// guardedWriter is used by a single drain goroutine
+type guardedWriter struct {
+	dst    io.Writer
+	errs   *drainErrors
+	failed bool
+}
+
+func (g *guardedWriter) Write(p []byte) (n int, err error) {
+	if g.failed {
+		return len(p), nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			g.failed = true
+			g.errs.record(r)
+			n, err = len(p), nil
+		}
+	}()
+
+	return g.dst.Write(p)
+}
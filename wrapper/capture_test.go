@@ -0,0 +1,208 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// writeInterleaved writes partial lines alternately to stdout and stderr
+func writeInterleaved(cmd *cobra.Command, args []string) {
+	// pause between writes so each partial line is drained on its own
+	steps := []struct {
+		file *os.File
+		text string
+	}{
+		{os.Stdout, "stdout: first"},
+		{os.Stderr, "stderr: first"},
+		{os.Stdout, " half\n"},
+		{os.Stderr, " half\n"},
+		{os.Stdout, "trailing"},
+	}
+	for _, step := range steps {
+		fmt.Fprint(step.file, step.text)
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestCobraWrapper_LineBufferedCapture(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: writeInterleaved,
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithLineBufferedCapture(), WithoutMirror())
+	output, err := wrapper.Execute([]string{})
+
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	for _, line := range []string{"stdout: first half\n", "stderr: first half\n"} {
+		if !strings.Contains(output.Stdout, line) {
+			t.Errorf("Expected whole line %q in output, got: %q", line, output.Stdout)
+		}
+	}
+	if !strings.HasSuffix(output.Stdout, "trailing") {
+		t.Errorf("Expected trailing partial line to be flushed, got: %q", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_UnbufferedCaptureInterleaves(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: writeInterleaved,
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror())
+	output, err := wrapper.Execute([]string{})
+
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	// without line buffering the partial lines land in the order they were written
+	if !strings.HasPrefix(output.Stdout, "stdout: firststderr: first") {
+		t.Errorf("Expected partial lines to interleave, got: %q", output.Stdout)
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	var sink strings.Builder
+	lw := &lineWriter{dst: &sink}
+
+	_, _ = lw.Write([]byte("one"))
+	if sink.Len() != 0 {
+		t.Fatalf("Expected partial line to be held back, got: %q", sink.String())
+	}
+
+	_, _ = lw.Write([]byte(" two\nthree\nfo"))
+	if sink.String() != "one two\nthree\n" {
+		t.Fatalf("Expected complete lines to be written, got: %q", sink.String())
+	}
+
+	lw.Flush()
+	if sink.String() != "one two\nthree\nfo" {
+		t.Errorf("Expected flush to write the partial line, got: %q", sink.String())
+	}
+}
+
+// writeBursts writes bursts of numbered lines to stdout and stderr
+func writeBursts(bursts, lines int) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		for b := 0; b < bursts; b++ {
+			for l := 0; l < lines; l++ {
+				fmt.Fprintf(os.Stdout, "stdout %d %d %s\n", b, l, strings.Repeat("o", 64))
+				fmt.Fprintf(os.Stderr, "stderr %d %d %s\n", b, l, strings.Repeat("e", 64))
+			}
+		}
+	}
+}
+
+func TestCobraWrapper_CaptureBufferSize(t *testing.T) {
+	const bursts, lines = 20, 500
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: writeBursts(bursts, lines),
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithCaptureBufferSize(2), WithoutMirror())
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	got := strings.Count(output.Stdout, "\n")
+	if expected := 2 * bursts * lines; got != expected {
+		t.Fatalf("Expected %d lines, got: %d", expected, got)
+	}
+
+	for _, line := range []string{"stdout 0 0 ", "stderr 0 0 ", "stdout 19 499 ", "stderr 19 499 "} {
+		if !strings.Contains(output.Stdout, line) {
+			t.Errorf("Expected output to contain %q", line)
+		}
+	}
+}
+
+func TestCobraWrapper_CaptureBufferSizeLineBuffered(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: writeInterleaved,
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithCaptureBufferSize(1), WithLineBufferedCapture(), WithoutMirror())
+
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if !strings.Contains(output.Stdout, "stdout: first half\n") || !strings.HasSuffix(output.Stdout, "trailing") {
+		t.Errorf("Got: %q", output.Stdout)
+	}
+}
+
+func BenchmarkExecute_BurstyOutput(b *testing.B) {
+	for _, size := range []int{0, 4, 64} {
+		b.Run(fmt.Sprintf("buffer=%d", size), func(b *testing.B) {
+			cmd := &cobra.Command{
+				Use: "test",
+				Run: writeBursts(10, 200),
+			}
+			wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithCaptureBufferSize(size), WithoutMirror())
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := wrapper.Execute([]string{}); err != nil {
+					b.Fatalf("Execute failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// panicWriter stands in for a mirror whose underlying file has been closed
+type panicWriter struct{}
+
+func (panicWriter) Write(p []byte) (int, error) {
+	panic("mirror closed")
+}
+
+func TestCobraWrapper_PanickingMirror(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			for i := 0; i < 3; i++ {
+				fmt.Printf("line %d\n", i)
+				fmt.Fprintf(os.Stderr, "err %d\n", i)
+			}
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd)
+	wrapper.mirrorStdout = panicWriter{}
+
+	output, err := wrapper.Execute([]string{})
+
+	if !errors.Is(err, ErrCapturePanicked) {
+		t.Fatalf("Expected ErrCapturePanicked, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "mirror closed") {
+		t.Errorf("Expected panic value in error, got: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if !strings.Contains(output.Stdout, fmt.Sprintf("line %d", i)) || !strings.Contains(output.Stdout, fmt.Sprintf("err %d", i)) {
+			t.Errorf("Expected output to be captured despite the mirror panicking. Got: %s", output.Stdout)
+		}
+	}
+	if output.ExitCode != 1 {
+		t.Errorf("Expected exit code 1, got: %d", output.ExitCode)
+	}
+}
@@ -0,0 +1,69 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+type checkErrKey struct{}
+
+// checkErrSlot holds the error a command passed to CheckErr
+type checkErrSlot struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (s *checkErrSlot) set(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *checkErrSlot) get() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// CheckErr is a replacement for cobra.CheckErr in commands run by the wrapper.
+// cobra.CheckErr calls os.Exit(1), which ends the Lambda process and loses the
+// captured output. CheckErr instead prints the error like cobra does, stops the
+// command and has Execute return err with the output captured so far. It does
+// nothing when err is nil and falls back to cobra.CheckErr when cmd is not run
+// by the wrapper, so commands behave the same from a terminal. Migrating is a
+// matter of replacing
+//
+//	cobra.CheckErr(err)
+//
+// with
+//
+//	wrapper.CheckErr(cmd, err)
+//
+// It must be called from the goroutine running the command, not one it started
+func CheckErr(cmd *cobra.Command, err error) {
+	if err == nil {
+		return
+	}
+
+	slot, ok := checkErrFrom(cmd.Context())
+	if !ok {
+		cobra.CheckErr(err)
+		return
+	}
+
+	_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+	slot.set(err)
+	runtime.Goexit()
+}
+
+func checkErrFrom(ctx context.Context) (*checkErrSlot, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	slot, ok := ctx.Value(checkErrKey{}).(*checkErrSlot)
+	return slot, ok
+}
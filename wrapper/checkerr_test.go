@@ -0,0 +1,55 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_CheckErr(t *testing.T) {
+	errLoad := errors.New("loading config failed")
+	ranAfter := false
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("starting")
+			if len(args) > 0 {
+				CheckErr(cmd, errLoad)
+				ranAfter = true
+			}
+			CheckErr(cmd, nil)
+			fmt.Println("done")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror())
+
+	output, err := wrapper.Execute([]string{"fail"})
+
+	if !errors.Is(err, errLoad) {
+		t.Fatalf("Expected the CheckErr error, got: %v", err)
+	}
+	if ranAfter {
+		t.Error("Expected the command to stop at CheckErr")
+	}
+	if output.ExitCode != 1 {
+		t.Errorf("Expected exit code 1, got: %d", output.ExitCode)
+	}
+	if !strings.Contains(output.Stdout, "starting") || !strings.Contains(output.Stdout, "Error: loading config failed") {
+		t.Errorf("Expected output and the error to be captured. Got: %s", output.Stdout)
+	}
+
+	// a nil error does not stop the command and the error does not carry over
+	output, err = wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.Stdout != "starting\ndone\n" {
+		t.Errorf("Got: %q", output.Stdout)
+	}
+}
@@ -0,0 +1,71 @@
+package wrapper
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/semaphore"
+)
+
+// ErrBusy is returned by Execute when the global concurrency limit set with
+// SetGlobalConcurrencyLimit is exhausted
+var ErrBusy = errors.New("wrapper: global concurrency limit reached")
+
+var (
+	globalLimitMu sync.Mutex
+	globalLimit   *semaphore.Weighted
+)
+
+// SetGlobalConcurrencyLimit caps the number of executions running at once across
+// every wrapper and handler in the process. Executions beyond the limit fail
+// immediately with ErrBusy rather than waiting. n <= 0 removes the limit, which
+// is the default. Executions already running keep their slot under the old limit.
+// A command abandoned at its deadline keeps its slot until it returns.
+//
+// The limit does not make concurrent executions of different trees independent:
+// each one swaps the process-wide os.Stdout and os.Stderr for its capture pipes,
+// so output written through them, rather than through cmd.OutOrStdout, may end
+// up in whichever execution swapped them last or escape capture once another
+// execution restores them. Keep the limit at 1 for commands printing with
+// fmt.Print* or writing to os.Stdout directly
+func SetGlobalConcurrencyLimit(n int) {
+	globalLimitMu.Lock()
+	defer globalLimitMu.Unlock()
+
+	if n <= 0 {
+		globalLimit = nil
+		return
+	}
+
+	globalLimit = semaphore.NewWeighted(int64(n))
+}
+
+// acquireGlobalSlot reserves a slot under the global concurrency limit, returning
+// the function releasing it
+func acquireGlobalSlot() (func(), error) {
+	globalLimitMu.Lock()
+	limit := globalLimit
+	globalLimitMu.Unlock()
+
+	if limit == nil {
+		return func() {}, nil
+	}
+
+	if !limit.TryAcquire(1) {
+		return nil, ErrBusy
+	}
+
+	return func() { limit.Release(1) }, nil
+}
+
+// treeLocks holds the mutex serializing executions of each command tree, keyed
+// by its root. Handlers build a wrapper per invocation, so the lock has to
+// outlive them for a command abandoned by one invocation to hold off the next
+var treeLocks sync.Map
+
+// treeLock returns the mutex serializing executions of the tree cmd belongs to
+func treeLock(cmd *cobra.Command) *sync.Mutex {
+	lock, _ := treeLocks.LoadOrStore(cmd.Root(), &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
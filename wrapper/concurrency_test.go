@@ -0,0 +1,147 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestSetGlobalConcurrencyLimit(t *testing.T) {
+	SetGlobalConcurrencyLimit(1)
+	defer SetGlobalConcurrencyLimit(0)
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	blocking := &cobra.Command{
+		Use: "blocking",
+		Run: func(cmd *cobra.Command, args []string) {
+			close(started)
+			<-unblock
+			cmd.Println("blocking done")
+		},
+	}
+	other := &cobra.Command{
+		Use: "other",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Println("other done")
+		},
+	}
+
+	first := NewCobraLambdaCLI(context.TODO(), blocking)
+	second := NewCobraLambdaCLI(context.TODO(), other)
+
+	type result struct {
+		output *CobraLambdaOutput
+		err    error
+	}
+	firstDone := make(chan result, 1)
+	go func() {
+		output, err := first.Execute([]string{})
+		firstDone <- result{output, err}
+	}()
+	<-started
+
+	// the only slot is held by the first wrapper
+	output, err := second.Execute([]string{})
+	if !errors.Is(err, ErrBusy) {
+		t.Fatalf("Expected ErrBusy, got: %v", err)
+	}
+	if output != nil {
+		t.Errorf("Expected nil output when busy, got: %+v", output)
+	}
+
+	_, err = NewTypedHandler(other)(context.Background(), CobraLambdaEvent{})
+	if !errors.Is(err, ErrBusy) {
+		t.Errorf("Expected ErrBusy from handler, got: %v", err)
+	}
+
+	close(unblock)
+	res := <-firstDone
+	if res.err != nil || !strings.Contains(res.output.Stdout, "blocking done") {
+		t.Fatalf("Expected first execution to complete, got: %+v, %v", res.output, res.err)
+	}
+
+	// the slot is released once the first execution returns
+	output, err = second.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "other done") {
+		t.Errorf("Got: %s", output.Stdout)
+	}
+}
+
+func TestSetGlobalConcurrencyLimitUnlimited(t *testing.T) {
+	SetGlobalConcurrencyLimit(1)
+	SetGlobalConcurrencyLimit(0)
+
+	release, err := acquireGlobalSlot()
+	if err != nil {
+		t.Fatalf("Expected no limit, got: %v", err)
+	}
+	defer release()
+
+	second, err := acquireGlobalSlot()
+	if err != nil {
+		t.Fatalf("Expected no limit, got: %v", err)
+	}
+	second()
+}
+
+func TestSetGlobalConcurrencyLimitHeldByAbandonedCommand(t *testing.T) {
+	SetGlobalConcurrencyLimit(1)
+	defer SetGlobalConcurrencyLimit(0)
+
+	unblock := make(chan struct{})
+	returned := make(chan struct{})
+	stuck := &cobra.Command{
+		Use: "stuck",
+		Run: func(cmd *cobra.Command, args []string) {
+			defer close(returned)
+			<-unblock
+		},
+	}
+	other := &cobra.Command{
+		Use: "other",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Println("other done")
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	first := NewCobraLambdaCLI(context.TODO(), stuck, WithoutMirror(), WithDeadlineMargin(50*time.Millisecond))
+	output, _ := first.ExecuteContext(ctx, []string{})
+	if output == nil || !output.TimedOut {
+		t.Fatalf("Expected the command to be abandoned, got: %+v", output)
+	}
+
+	// the abandoned command still runs and keeps its slot
+	second := NewCobraLambdaCLI(context.TODO(), other, WithoutMirror())
+	if _, err := second.Execute([]string{}); !errors.Is(err, ErrBusy) {
+		t.Fatalf("Expected ErrBusy while the abandoned command runs, got: %v", err)
+	}
+
+	close(unblock)
+	<-returned
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		output, err := second.Execute([]string{})
+		if err == nil {
+			if !strings.Contains(output.Stdout, "other done") {
+				t.Errorf("Got: %s", output.Stdout)
+			}
+			break
+		}
+		if !errors.Is(err, ErrBusy) || time.Now().After(deadline) {
+			t.Fatalf("Expected the slot to be released once the command returned, got: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,62 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// ConfirmationAnnotation marks a destructive command that would prompt for
+// confirmation on a terminal. On Lambda there is nobody to answer the prompt,
+// so Execute refuses to run it unless the invocation is confirmed, with
+// {"confirm": true} in the event or ConfirmedContext. The annotation value is
+// ignored
+const ConfirmationAnnotation = "requires-confirmation"
+
+// ErrConfirmationRequired is returned when an unconfirmed invocation resolves to
+// a command annotated with ConfirmationAnnotation
+var ErrConfirmationRequired = errors.New("wrapper: command requires confirmation")
+
+type confirmedKey struct{}
+
+// ConfirmedContext marks executions with ctx as confirmed, for callers of
+// ExecuteContext running commands annotated with ConfirmationAnnotation
+func ConfirmedContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, confirmedKey{}, true)
+}
+
+func withConfirmed(ctx context.Context, confirmed bool) context.Context {
+	if !confirmed {
+		return ctx
+	}
+	return ConfirmedContext(ctx)
+}
+
+// RequiresConfirmation reports whether args resolve to a command below root
+// annotated with ConfirmationAnnotation
+func RequiresConfirmation(root *cobra.Command, args []string) bool {
+	target, _, err := root.Find(args)
+	if err != nil || target == nil {
+		return false
+	}
+
+	_, ok := target.Annotations[ConfirmationAnnotation]
+	return ok
+}
+
+// checkConfirmation fails when args require confirmation and the execution
+// context does not carry it
+func (w *CobraLambda) checkConfirmation(ctx context.Context, args []string) error {
+	if !RequiresConfirmation(w.cmd, args) {
+		return nil
+	}
+
+	if confirmed, _ := ctx.Value(confirmedKey{}).(bool); confirmed {
+		return nil
+	}
+
+	target, _, _ := w.cmd.Find(args)
+	return fmt.Errorf("%w: %q, set \"confirm\": true in the event to run it", ErrConfirmationRequired, target.CommandPath())
+}
@@ -0,0 +1,111 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestNewCobrLambdaHandler_RequiresConfirmation(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		ran   bool
+		fails bool
+	}{
+		{name: "unconfirmed", event: `{"args":["drop"]}`, fails: true},
+		{name: "confirm false", event: `{"args":["drop"],"confirm":false}`, fails: true},
+		{name: "confirmed", event: `{"args":["drop"],"confirm":true}`, ran: true},
+		{name: "not destructive", event: `{"args":["status"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran bool
+			root := &cobra.Command{Use: "db"}
+			root.AddCommand(
+				&cobra.Command{
+					Use:         "drop",
+					Annotations: map[string]string{ConfirmationAnnotation: ""},
+					Run: func(cmd *cobra.Command, args []string) {
+						ran = true
+						fmt.Print("dropped")
+					},
+				},
+				&cobra.Command{
+					Use: "status",
+					Run: func(cmd *cobra.Command, args []string) {
+						fmt.Print("ok")
+					},
+				},
+			)
+
+			handler := NewCobrLambdaHandler(root, WithoutMirror())
+
+			_, err := handler(context.TODO(), json.RawMessage(tt.event))
+
+			if tt.fails && !errors.Is(err, ErrConfirmationRequired) {
+				t.Fatalf("Expected ErrConfirmationRequired, got %v", err)
+			}
+			if !tt.fails && err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if ran != tt.ran {
+				t.Errorf("Expected ran %v, got %v", tt.ran, ran)
+			}
+		})
+	}
+}
+
+func TestCobraWrapper_ConfirmedContext(t *testing.T) {
+	var ran bool
+	root := &cobra.Command{Use: "db"}
+	root.AddCommand(&cobra.Command{
+		Use:         "drop",
+		Annotations: map[string]string{ConfirmationAnnotation: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			ran = true
+			fmt.Print("dropped")
+		},
+	})
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), root, WithoutMirror())
+
+	if _, err := wrapper.Execute([]string{"drop"}); !errors.Is(err, ErrConfirmationRequired) {
+		t.Fatalf("Expected ErrConfirmationRequired, got %v", err)
+	}
+
+	output, err := wrapper.ExecuteContext(ConfirmedContext(context.TODO()), []string{"drop"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !ran || output.Stdout != "dropped" {
+		t.Errorf("Got: %s", output.Stdout)
+	}
+}
+
+func TestRequiresConfirmation(t *testing.T) {
+	root := &cobra.Command{Use: "db"}
+	root.AddCommand(
+		&cobra.Command{
+			Use:         "drop",
+			Annotations: map[string]string{ConfirmationAnnotation: ""},
+			Run:         func(cmd *cobra.Command, args []string) {},
+		},
+		&cobra.Command{
+			Use: "status",
+			Run: func(cmd *cobra.Command, args []string) {},
+		},
+	)
+
+	if !RequiresConfirmation(root, []string{"drop", "--force"}) {
+		t.Error("Expected drop to require confirmation")
+	}
+	if RequiresConfirmation(root, []string{"status"}) || RequiresConfirmation(root, []string{}) {
+		t.Error("Expected status and the root not to require confirmation")
+	}
+}
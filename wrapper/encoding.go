@@ -0,0 +1,66 @@
+package wrapper
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidUTF8 is returned by Execute when captured output is not valid UTF-8
+// and the wrapper is configured with InvalidUTF8Error
+var ErrInvalidUTF8 = errors.New("wrapper: captured output is not valid UTF-8")
+
+// EncodingBase64 is reported in CobraLambdaOutput.Encoding when Stdout holds
+// base64 encoded bytes, and in StderrEncoding when Stderr does
+const EncodingBase64 = "base64"
+
+// InvalidUTF8Mode controls how Execute handles captured output that is not valid UTF-8
+type InvalidUTF8Mode int
+
+const (
+	// InvalidUTF8Keep returns output verbatim, leaving JSON marshaling to mangle invalid bytes
+	InvalidUTF8Keep InvalidUTF8Mode = iota
+	// InvalidUTF8Replace replaces each invalid sequence with the Unicode replacement character
+	InvalidUTF8Replace
+	// InvalidUTF8Base64 base64 encodes the whole of Stdout or Stderr and sets
+	// Encoding or StderrEncoding to "base64"
+	InvalidUTF8Base64
+	// InvalidUTF8Error returns ErrInvalidUTF8 alongside the verbatim output
+	InvalidUTF8Error
+)
+
+// WithInvalidUTF8 sets how output containing invalid UTF-8 is handled
+func WithInvalidUTF8(mode InvalidUTF8Mode) Option {
+	return func(w *CobraLambda) {
+		w.invalidUTF8 = mode
+	}
+}
+
+// encodeOutput applies the configured InvalidUTF8Mode to Stdout and Stderr
+func (w *CobraLambda) encodeOutput(output *CobraLambdaOutput) error {
+	if err := w.encodeStream(&output.Stdout, &output.Encoding); err != nil {
+		return err
+	}
+	return w.encodeStream(&output.Stderr, &output.StderrEncoding)
+}
+
+// encodeStream applies the configured InvalidUTF8Mode to the captured stream s,
+// setting encoding when it is base64 encoded
+func (w *CobraLambda) encodeStream(s *string, encoding *string) error {
+	if utf8.ValidString(*s) {
+		return nil
+	}
+
+	switch w.invalidUTF8 {
+	case InvalidUTF8Replace:
+		*s = strings.ToValidUTF8(*s, string(utf8.RuneError))
+	case InvalidUTF8Base64:
+		*s = base64.StdEncoding.EncodeToString([]byte(*s))
+		*encoding = EncodingBase64
+	case InvalidUTF8Error:
+		return ErrInvalidUTF8
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newInvalidUTF8Command() *cobra.Command {
+	return &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			_, _ = os.Stdout.Write([]byte("ok\xff\xfe"))
+		},
+	}
+}
+
+func TestCobraWrapper_InvalidUTF8Keep(t *testing.T) {
+	wrapper := NewCobraLambdaCLI(context.TODO(), newInvalidUTF8Command())
+	output, err := wrapper.Execute([]string{})
+
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.Stdout != "ok\xff\xfe" {
+		t.Errorf("Expected verbatim output, got: %q", output.Stdout)
+	}
+	if output.Encoding != "" {
+		t.Errorf("Expected empty Encoding, got: %s", output.Encoding)
+	}
+}
+
+func TestCobraWrapper_InvalidUTF8Replace(t *testing.T) {
+	wrapper := NewCobraLambdaCLI(context.TODO(), newInvalidUTF8Command(), WithInvalidUTF8(InvalidUTF8Replace))
+	output, err := wrapper.Execute([]string{})
+
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.Stdout != "ok�" {
+		t.Errorf("Expected replaced output, got: %q", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_InvalidUTF8Base64(t *testing.T) {
+	wrapper := NewCobraLambdaCLI(context.TODO(), newInvalidUTF8Command(), WithInvalidUTF8(InvalidUTF8Base64))
+	output, err := wrapper.Execute([]string{})
+
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.Encoding != EncodingBase64 {
+		t.Errorf("Expected Encoding %q, got: %q", EncodingBase64, output.Encoding)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(output.Stdout)
+	if err != nil {
+		t.Fatalf("Failed to decode Stdout: %v", err)
+	}
+	if string(decoded) != "ok\xff\xfe" {
+		t.Errorf("Expected original bytes, got: %q", decoded)
+	}
+}
+
+func TestCobraWrapper_InvalidUTF8Base64Stderr(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Fprint(cmd.OutOrStdout(), "ok")
+			fmt.Fprint(cmd.ErrOrStderr(), "err\xff\xfe")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithSeparateStderr(), WithInvalidUTF8(InvalidUTF8Base64))
+	output, err := wrapper.Execute([]string{})
+
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.Stdout != "ok" || output.Encoding != "" {
+		t.Errorf("Expected valid stdout untouched, got: %+v", output)
+	}
+	if output.StderrEncoding != EncodingBase64 {
+		t.Errorf("Expected StderrEncoding %q, got: %q", EncodingBase64, output.StderrEncoding)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(output.Stderr)
+	if err != nil {
+		t.Fatalf("Failed to decode Stderr: %v", err)
+	}
+	if string(decoded) != "err\xff\xfe" {
+		t.Errorf("Expected original bytes, got: %q", decoded)
+	}
+}
+
+func TestCobraWrapper_InvalidUTF8Error(t *testing.T) {
+	wrapper := NewCobraLambdaCLI(context.TODO(), newInvalidUTF8Command(), WithInvalidUTF8(InvalidUTF8Error))
+	output, err := wrapper.Execute([]string{})
+
+	if !errors.Is(err, ErrInvalidUTF8) {
+		t.Fatalf("Expected ErrInvalidUTF8, got: %v", err)
+	}
+	if output == nil || output.Stdout != "ok\xff\xfe" {
+		t.Errorf("Expected verbatim output alongside error, got: %+v", output)
+	}
+}
+
+func TestCobraWrapper_ValidUTF8Untouched(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Print("héllo")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithInvalidUTF8(InvalidUTF8Base64))
+	output, err := wrapper.Execute([]string{})
+
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.Stdout != "héllo" || output.Encoding != "" {
+		t.Errorf("Expected valid output untouched, got: %+v", output)
+	}
+}
@@ -0,0 +1,48 @@
+package wrapper
+
+import "context"
+
+// EnvelopeVersion is the version of the EnvelopeV1 schema
+const EnvelopeVersion = 1
+
+// EnvelopeV1 is the versioned response returned by NewCobrLambdaHandler when
+// WithEnvelopeV1 is set. Fields are only ever added to it, so clients can rely
+// on its shape. It decodes into CobraLambdaOutput with DecodeResponse
+type EnvelopeV1 struct {
+	Version int    `json:"version"`
+	Stdout  string `json:"stdout"`
+	// Stderr is empty unless WithSeparateStderr or WithStderrFailThreshold is
+	// set, stderr is part of Stdout otherwise
+	Stderr     string `json:"stderr"`
+	ExitCode   int    `json:"exitCode"`
+	DurationMs int64  `json:"durationMs"`
+	// RequestID is the AWS request ID of the invocation
+	RequestID string `json:"requestId"`
+	// Encoding is set to "base64" when Stdout was base64 encoded due to invalid UTF-8
+	Encoding string `json:"encoding,omitempty"`
+	// StderrEncoding is set to "base64" when Stderr was base64 encoded due to invalid UTF-8
+	StderrEncoding string `json:"stderrEncoding,omitempty"`
+}
+
+// WithEnvelopeV1 makes NewCobrLambdaHandler respond with an EnvelopeV1 instead
+// of CobraLambdaOutput, a stable schema for client tools. Events asking for
+// ResponseFormatMsgpack still get the MessagePack encoded output. It has no
+// effect on NewTypedHandler, which always returns CobraLambdaOutput
+func WithEnvelopeV1() Option {
+	return func(w *CobraLambda) {
+		w.responseEncoder = encodeEnvelopeV1
+	}
+}
+
+func encodeEnvelopeV1(ctx context.Context, output *CobraLambdaOutput) any {
+	return &EnvelopeV1{
+		Version:        EnvelopeVersion,
+		Stdout:         output.Stdout,
+		Stderr:         output.Stderr,
+		ExitCode:       output.ExitCode,
+		DurationMs:     output.DurationMs,
+		RequestID:      RequestID(ctx),
+		Encoding:       output.Encoding,
+		StderrEncoding: output.StderrEncoding,
+	}
+}
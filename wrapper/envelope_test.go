@@ -0,0 +1,111 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/spf13/cobra"
+)
+
+func TestNewCobrLambdaHandler_EnvelopeV1(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Print("out")
+			fmt.Fprint(os.Stderr, "err")
+		},
+	}
+
+	handler := NewCobrLambdaHandler(cmd, WithoutMirror(), WithSeparateStderr(), WithEnvelopeV1())
+
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "req-123"})
+	response, err := handler(ctx, json.RawMessage(`{"args": []}`))
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+
+	envelope, ok := response.(*EnvelopeV1)
+	if !ok {
+		t.Fatalf("Expected *EnvelopeV1, got %T", response)
+	}
+
+	if envelope.Version != 1 || envelope.Stdout != "out" || envelope.Stderr != "err" || envelope.ExitCode != 0 {
+		t.Errorf("Unexpected envelope: %+v", envelope)
+	}
+	if envelope.RequestID != "req-123" {
+		t.Errorf("Expected request ID req-123, got %q", envelope.RequestID)
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	for _, key := range []string{"version", "stdout", "stderr", "exitCode", "durationMs", "requestId"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("Expected field %q in %s", key, data)
+		}
+	}
+
+	// existing clients decoding the legacy output keep working
+	output, err := DecodeResponse(data)
+	if err != nil {
+		t.Fatalf("DecodeResponse failed: %v", err)
+	}
+	if output.Stdout != "out" || output.Stderr != "err" {
+		t.Errorf("Unexpected decoded output: %+v", output)
+	}
+}
+
+func TestNewCobrLambdaHandler_EnvelopeV1ExitCode(t *testing.T) {
+	cmd := &cobra.Command{
+		Use:           "test",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return &ExitError{Code: 3, Err: errFailed}
+		},
+	}
+
+	handler := NewCobrLambdaHandler(cmd, WithoutMirror(), WithEnvelopeV1())
+
+	response, err := handler(context.Background(), json.RawMessage(`{"args": []}`))
+	if err == nil {
+		t.Fatal("Expected the command error to be returned")
+	}
+
+	envelope, ok := response.(*EnvelopeV1)
+	if !ok {
+		t.Fatalf("Expected *EnvelopeV1, got %T", response)
+	}
+	if envelope.ExitCode != 3 || envelope.RequestID != "" {
+		t.Errorf("Unexpected envelope: %+v", envelope)
+	}
+}
+
+func TestNewCobrLambdaHandler_LegacyOutputByDefault(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Print("out")
+		},
+	}
+
+	handler := NewCobrLambdaHandler(cmd, WithoutMirror())
+
+	response, err := handler(context.Background(), json.RawMessage(`{"args": []}`))
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+	if _, ok := response.(*CobraLambdaOutput); !ok {
+		t.Fatalf("Expected *CobraLambdaOutput, got %T", response)
+	}
+}
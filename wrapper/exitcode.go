@@ -0,0 +1,111 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ExitError is returned by a command to fail with a specific exit code, which is
+// reported in CobraLambdaOutput.ExitCode instead of 1
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// exitCoder is implemented by errors carrying an exit code, ExitError and the
+// *exec.ExitError of a process run by the command
+type exitCoder interface {
+	ExitCode() int
+}
+
+// exitCode returns the exit code for err, 0 for nil, the code carried by err or 1.
+// Codes of processes killed by a signal, reported as -1, become 1
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var coder exitCoder
+	if errors.As(err, &coder) && coder.ExitCode() > 0 {
+		return coder.ExitCode()
+	}
+
+	return 1
+}
+
+// WithSuccessExitCodes treats the command failing with one of codes as success,
+// for tools using non-zero codes for non-error conditions such as grep exiting
+// with 1 when nothing matched. Execute returns no error and the code is still
+// reported in CobraLambdaOutput.ExitCode. Only errors carrying an exit code,
+// ExitError or an *exec.ExitError, are matched, plain errors always fail
+func WithSuccessExitCodes(codes []int) Option {
+	return func(w *CobraLambda) {
+		w.successExitCodes = append([]int{}, codes...)
+	}
+}
+
+// successExitCode reports the exit code of err and whether it is one of the
+// codes configured as success
+func (w *CobraLambda) successExitCode(err error) (int, bool) {
+	var coder exitCoder
+	if !errors.As(err, &coder) {
+		return exitCode(err), false
+	}
+
+	code := exitCode(err)
+	return code, slices.Contains(w.successExitCodes, code)
+}
+
+type exitCodeKey struct{}
+
+// exitCodeSlot holds the exit code a command set with SetExitCode
+type exitCodeSlot struct {
+	mu   sync.Mutex
+	code int
+	set  bool
+}
+
+func (s *exitCodeSlot) store(code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.code, s.set = code, true
+}
+
+func (s *exitCodeSlot) get() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.code, s.set
+}
+
+// SetExitCode sets the exit code reported in CobraLambdaOutput.ExitCode, e.g.
+// wrapper.SetExitCode(cmd.Context(), 2), for commands signalling a condition
+// such as "changes found" while still succeeding. Execute does not return an
+// error for it. A non-zero code replaces the one derived from an error the
+// command returns. It is a no-op when the command is not run by the wrapper
+func SetExitCode(ctx context.Context, code int) {
+	if ctx == nil {
+		return
+	}
+
+	if slot, ok := ctx.Value(exitCodeKey{}).(*exitCodeSlot); ok {
+		slot.store(code)
+	}
+}
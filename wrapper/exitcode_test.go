@@ -0,0 +1,138 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_SuccessExitCodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		codes []int
+		want  int
+		fails bool
+	}{
+		{name: "success code", err: &ExitError{Code: 1}, codes: []int{1}, want: 1},
+		{name: "other success code", err: &ExitError{Code: 2}, codes: []int{1, 2}, want: 2},
+		{name: "code not listed", err: &ExitError{Code: 2}, codes: []int{1}, want: 2, fails: true},
+		{name: "not configured", err: &ExitError{Code: 1}, want: 1, fails: true},
+		{name: "plain error", err: errors.New("failed"), codes: []int{1}, want: 1, fails: true},
+		{name: "wrapped", err: fmt.Errorf("grep: %w", &ExitError{Code: 1}), codes: []int{1}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{
+				Use:           "test",
+				SilenceErrors: true,
+				SilenceUsage:  true,
+				RunE: func(cmd *cobra.Command, args []string) error {
+					fmt.Println("searched")
+					return tt.err
+				},
+			}
+
+			wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithSuccessExitCodes(tt.codes))
+
+			output, err := wrapper.Execute([]string{})
+
+			if tt.fails && err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !tt.fails && err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+
+			if output.ExitCode != tt.want {
+				t.Errorf("Expected exit code %d, got %d", tt.want, output.ExitCode)
+			}
+
+			if output.Stdout != "searched\n" {
+				t.Errorf("Got: %s", output.Stdout)
+			}
+		})
+	}
+}
+
+func TestCobraWrapper_ExecExitCode(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exec.Command("sh", "-c", "exit 1").Run()
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithSuccessExitCodes([]int{1}))
+
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if output.ExitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", output.ExitCode)
+	}
+}
+
+func TestExitError(t *testing.T) {
+	inner := errors.New("no match")
+	err := &ExitError{Code: 3, Err: inner}
+
+	if err.Error() != "no match" || !errors.Is(err, inner) {
+		t.Errorf("Expected ExitError to wrap its error, got %q", err.Error())
+	}
+
+	if got := (&ExitError{Code: 3}).Error(); got != "exit status 3" {
+		t.Errorf("Got: %s", got)
+	}
+
+	if exitCode(err) != 3 || exitCode(nil) != 0 || exitCode(inner) != 1 {
+		t.Error("Expected exit codes 3, 0 and 1")
+	}
+}
+
+func TestCobraWrapper_SetExitCode(t *testing.T) {
+	cmd := &cobra.Command{
+		Use:           "test",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				SetExitCode(cmd.Context(), 4)
+				return errFailed
+			}
+			cmd.Print("changes found")
+			SetExitCode(cmd.Context(), 2)
+			return nil
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror())
+
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if output.ExitCode != 2 || output.Stdout != "changes found" {
+		t.Errorf("Expected exit code 2, got %+v", output)
+	}
+
+	output, err = wrapper.Execute([]string{"fail"})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Expected errFailed, got %v", err)
+	}
+	if output.ExitCode != 4 {
+		t.Errorf("Expected the set code to replace 1, got %d", output.ExitCode)
+	}
+}
+
+func TestSetExitCode_WithoutWrapper(t *testing.T) {
+	// must not panic outside the wrapper
+	SetExitCode(context.Background(), 2)
+}
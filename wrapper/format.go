@@ -0,0 +1,80 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// FormatsAnnotation is the command annotation listing the output formats the
+// command can emit, comma separated such as "table,json,yaml". Events asking
+// for a format the command does not list are rejected with ErrUnsupportedFormat
+const FormatsAnnotation = "formats"
+
+// ErrUnsupportedFormat is returned for events asking for an output format the
+// command does not declare in its FormatsAnnotation
+var ErrUnsupportedFormat = errors.New("wrapper: unsupported output format")
+
+// ErrFormatFlagNotSet is returned for events setting Format when WithFormatFlag
+// is not set
+var ErrFormatFlagNotSet = errors.New("wrapper: event format requires WithFormatFlag")
+
+// WithFormatFlag passes the event's Format to the command as --name, e.g.
+// WithFormatFlag("output") turns {"format": "json"} into --output=json. The flag
+// is added as a persistent string flag on the root command unless the tree
+// already defines it, commands read it like any other flag
+func WithFormatFlag(name string) Option {
+	return func(w *CobraLambda) {
+		w.outputFormatFlag = name
+	}
+}
+
+// addFormatFlag defines the persistent format flag on cmd when no flag of that
+// name exists yet
+func addFormatFlag(cmd *cobra.Command, name string) {
+	if cmd.PersistentFlags().Lookup(name) != nil || cmd.Flags().Lookup(name) != nil {
+		return
+	}
+
+	cmd.PersistentFlags().String(name, "", "Output format")
+}
+
+// applyFormat adds the format flag for format to args, ahead of any "--" so it
+// is not taken as a positional argument
+func (w *CobraLambda) applyFormat(args []string, format string) ([]string, error) {
+	if format == "" {
+		return args, nil
+	}
+
+	if w.outputFormatFlag == "" {
+		return nil, ErrFormatFlagNotSet
+	}
+
+	if target, _, err := w.cmd.Find(args); err == nil && target != nil {
+		if declared, ok := target.Annotations[FormatsAnnotation]; ok {
+			formats := strings.Split(declared, ",")
+			for i := range formats {
+				formats[i] = strings.TrimSpace(formats[i])
+			}
+
+			if !slices.Contains(formats, format) {
+				return nil, fmt.Errorf("%w: %q, command %q supports %s", ErrUnsupportedFormat, format, target.CommandPath(), strings.Join(formats, ", "))
+			}
+		}
+	}
+
+	flag := "--" + w.outputFormatFlag + "=" + format
+
+	end := slices.Index(args, "--")
+	if end < 0 {
+		end = len(args)
+	}
+
+	withFormat := make([]string, 0, len(args)+1)
+	withFormat = append(withFormat, args[:end]...)
+	withFormat = append(withFormat, flag)
+	return append(withFormat, args[end:]...), nil
+}
@@ -0,0 +1,98 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestNewTypedHandler_FormatFlag(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	root.AddCommand(&cobra.Command{
+		Use:         "report",
+		Annotations: map[string]string{FormatsAnnotation: "table, json,yaml"},
+		Run: func(cmd *cobra.Command, args []string) {
+			format, _ := cmd.Flags().GetString("output")
+			cmd.Printf("format=%s args=%v", format, args)
+		},
+	})
+
+	handler := NewTypedHandler(root, WithoutMirror(), WithFormatFlag("output"))
+
+	for _, tt := range []struct {
+		event CobraLambdaEvent
+		want  string
+	}{
+		{event: CobraLambdaEvent{Args: []string{"report"}, Format: "json"}, want: "format=json args=[]"},
+		{event: CobraLambdaEvent{Path: []string{"report"}, Format: "yaml"}, want: "format=yaml args=[]"},
+		{event: CobraLambdaEvent{Args: []string{"report", "--", "--not-a-flag"}, Format: "json"}, want: "format=json args=[--not-a-flag]"},
+		// flags reset between invocations so no format leaks into the next one
+		{event: CobraLambdaEvent{Args: []string{"report"}}, want: "format= args=[]"},
+	} {
+		output, err := handler(context.TODO(), tt.event)
+		if err != nil {
+			t.Fatalf("Execute failed for %+v: %v", tt.event, err)
+		}
+		if output.Stdout != tt.want {
+			t.Errorf("Expected %q, got %q", tt.want, output.Stdout)
+		}
+	}
+}
+
+func TestNewTypedHandler_UnsupportedFormat(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	root.AddCommand(&cobra.Command{
+		Use:         "report",
+		Annotations: map[string]string{FormatsAnnotation: "table, json,yaml"},
+		Run:         func(cmd *cobra.Command, args []string) {},
+	})
+
+	handler := NewTypedHandler(root, WithoutMirror(), WithFormatFlag("output"))
+
+	_, err := handler(context.TODO(), CobraLambdaEvent{Args: []string{"report"}, Format: "xml"})
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func TestNewTypedHandler_FormatWithoutFlag(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	root.AddCommand(&cobra.Command{
+		Use:         "report",
+		Annotations: map[string]string{FormatsAnnotation: "table, json,yaml"},
+		Run:         func(cmd *cobra.Command, args []string) {},
+	})
+
+	handler := NewTypedHandler(root, WithoutMirror())
+
+	_, err := handler(context.TODO(), CobraLambdaEvent{Args: []string{"report"}, Format: "json"})
+	if !errors.Is(err, ErrFormatFlagNotSet) {
+		t.Errorf("Expected ErrFormatFlagNotSet, got %v", err)
+	}
+}
+
+func TestWithFormatFlag_ExistingFlag(t *testing.T) {
+	var format string
+	root := &cobra.Command{
+		Use: "root",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Print(format)
+		},
+	}
+	root.Flags().StringVarP(&format, "output", "o", "table", "Output format")
+
+	handler := NewTypedHandler(root, WithoutMirror(), WithFormatFlag("output"))
+
+	output, err := handler(context.TODO(), CobraLambdaEvent{Format: "json"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.Stdout != "json" {
+		t.Errorf("Expected the existing flag to receive the format, got %q", output.Stdout)
+	}
+	if root.PersistentFlags().Lookup("output") != nil {
+		t.Error("Expected no persistent flag to be added alongside the existing one")
+	}
+}
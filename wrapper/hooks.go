@@ -0,0 +1,189 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// PreExecHook is called by the handler with the parsed event before the command runs
+type PreExecHook func(ctx context.Context, event *CobraLambdaEvent)
+
+// PostExecHook is called by the handler after the command has run with its output,
+// error and how long execution took. output may be nil if execution failed early
+type PostExecHook func(ctx context.Context, event *CobraLambdaEvent, output *CobraLambdaOutput, err error, elapsed time.Duration)
+
+// WithPreExecHook registers a hook invoked after the event is unmarshaled and
+// before the command executes. The hook receives a copy of the event so it
+// cannot change the arguments passed to the command
+func WithPreExecHook(hook PreExecHook) Option {
+	return func(w *CobraLambda) {
+		w.preExecHooks = append(w.preExecHooks, hook)
+	}
+}
+
+// WithPostExecHook registers a hook invoked exactly once after every execution,
+// including when the command returns an error or panics. A panic is reported to
+// the hook as an error and then re-raised
+func WithPostExecHook(hook PostExecHook) Option {
+	return func(w *CobraLambda) {
+		w.postExecHooks = append(w.postExecHooks, hook)
+	}
+}
+
+// ContextFactory builds the context a handler executes the command with from
+// the invocation context and event
+type ContextFactory func(parent context.Context, event *CobraLambdaEvent) context.Context
+
+// WithContextFactory has handlers build the context passed to ExecuteContext
+// with factory, e.g. to start a tracing span or apply a timeout for every
+// invocation. The factory receives a copy of the event so it cannot change the
+// arguments passed to the command. Returning nil keeps the parent context.
+// By default the invocation context is used unchanged
+func WithContextFactory(factory ContextFactory) Option {
+	return func(w *CobraLambda) {
+		w.contextFactory = factory
+	}
+}
+
+// commandContext returns the context to execute the command for event with
+func (w *CobraLambda) commandContext(ctx context.Context, event *CobraLambdaEvent) context.Context {
+	if w.contextFactory != nil {
+		if built := w.contextFactory(ctx, event.clone()); built != nil {
+			ctx = built
+		}
+	}
+
+	return withConfirmed(withEventContext(withStdin(ctx, event.Stdin), event.Context), event.Confirm)
+}
+
+// RequestID returns the AWS request ID of the current Lambda invocation, or an
+// empty string when ctx does not carry a Lambda context
+func RequestID(ctx context.Context) string {
+	lc, ok := lambdacontext.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return lc.AwsRequestID
+}
+
+// ClientContext returns the client context the caller attached to the current
+// Lambda invocation, such as the mobile SDK client details or custom values.
+// ok is false when ctx does not carry a Lambda context
+func ClientContext(ctx context.Context) (lambdacontext.ClientContext, bool) {
+	lc, ok := lambdacontext.FromContext(ctx)
+	if !ok {
+		return lambdacontext.ClientContext{}, false
+	}
+	return lc.ClientContext, true
+}
+
+type eventContextKey struct{}
+
+// EventContext returns the values the caller passed in the event's Context,
+// e.g. wrapper.EventContext(cmd.Context())["correlation_id"]. It returns nil when
+// the event carried none or ctx does not come from a handler
+func EventContext(ctx context.Context) map[string]string {
+	values, _ := ctx.Value(eventContextKey{}).(map[string]string)
+	return values
+}
+
+// withEventContext attaches a copy of the event's Context to ctx so the command
+// cannot change the values seen by hooks
+func withEventContext(ctx context.Context, values map[string]string) context.Context {
+	if len(values) == 0 {
+		return ctx
+	}
+
+	copied := make(map[string]string, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return context.WithValue(ctx, eventContextKey{}, copied)
+}
+
+func (w *CobraLambda) runPreExecHooks(ctx context.Context, event *CobraLambdaEvent) {
+	w.recordEvent(ctx, event)
+
+	for _, hook := range w.preExecHooks {
+		hook(ctx, event.clone())
+	}
+}
+
+// executeWithHooks runs the command for event, invoking post-exec hooks once
+// execution has finished or panicked
+func (w *CobraLambda) executeWithHooks(ctx context.Context, event *CobraLambdaEvent) (*CobraLambdaOutput, error) {
+	var output *CobraLambdaOutput
+	var err error
+
+	start := time.Now()
+
+	defer func() {
+		if len(w.postExecHooks) == 0 {
+			return
+		}
+
+		r := recover()
+		hookErr := err
+		if r != nil {
+			hookErr = fmt.Errorf("wrapper: command panicked: %v", r)
+		}
+
+		elapsed := time.Since(start)
+		for _, hook := range w.postExecHooks {
+			hook(ctx, event.clone(), output, hookErr, elapsed)
+		}
+
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	args, err := w.commandArgs(event)
+	if err != nil {
+		return nil, err
+	}
+
+	// separate invocations with the same args are only duplicates when the
+	// caller says so
+	key := event.IdempotencyKey
+	idempotent := w.idempotencyStore != nil && key != ""
+	if idempotent {
+		var cached bool
+		if output, cached, err = w.cachedOutput(ctx, key); err != nil || cached {
+			return output, err
+		}
+	}
+
+	output, err = w.ExecuteContext(w.commandContext(ctx, event), args)
+
+	w.logError(ctx, args, err)
+	w.warnOutputSize(ctx, args, output)
+
+	if idempotent && err == nil {
+		// the command already ran, a failure to record it must not trigger a retry
+		if putErr := w.idempotencyStore.Put(ctx, key, output); putErr != nil {
+			w.logError(ctx, args, fmt.Errorf("wrapper: idempotency store: %w", putErr))
+		}
+	}
+
+	return output, err
+}
+
+type rawEventKey struct{}
+
+// RawEvent returns the event JSON the invocation was received with, e.g.
+// wrapper.RawEvent(cmd.Context()), for fields beyond args such as custom
+// metadata. It is set by NewCobrLambdaHandler and for each record by the batch
+// handlers, and is nil otherwise. The returned bytes must not be modified
+func RawEvent(ctx context.Context) json.RawMessage {
+	raw, _ := ctx.Value(rawEventKey{}).(json.RawMessage)
+	return raw
+}
+
+func withRawEvent(ctx context.Context, raw json.RawMessage) context.Context {
+	return context.WithValue(ctx, rawEventKey{}, raw)
+}
@@ -0,0 +1,291 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/spf13/cobra"
+)
+
+func TestNewCobrLambdaHandler_PreExecHook(t *testing.T) {
+	var receivedArgs []string
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			receivedArgs = args
+		},
+	}
+
+	var hookEvent *CobraLambdaEvent
+	var hookRequestID string
+	hookCalledBeforeRun := false
+
+	handler := NewCobrLambdaHandler(cmd, WithPreExecHook(func(ctx context.Context, event *CobraLambdaEvent) {
+		hookEvent = event
+		hookRequestID = RequestID(ctx)
+		hookCalledBeforeRun = receivedArgs == nil
+
+		// Mutating the event must not affect the command
+		event.Args[0] = "mutated"
+	}))
+
+	eventJSON, err := json.Marshal(CobraLambdaEvent{Args: []string{"one", "two"}})
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{
+		AwsRequestID: "req-123",
+	})
+
+	if _, err := handler(ctx, eventJSON); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if hookEvent == nil {
+		t.Fatal("Pre-exec hook was not called")
+	}
+	if !hookCalledBeforeRun {
+		t.Error("Pre-exec hook was not called before the command ran")
+	}
+	if hookRequestID != "req-123" {
+		t.Errorf("Expected request id 'req-123', got: %q", hookRequestID)
+	}
+	if len(receivedArgs) != 2 || receivedArgs[0] != "one" || receivedArgs[1] != "two" {
+		t.Errorf("Expected command args [one two], got: %v", receivedArgs)
+	}
+}
+
+func TestNewCobrLambdaHandler_PreExecHookSkippedOnInvalidEvent(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
+
+	called := false
+	handler := NewCobrLambdaHandler(cmd, WithPreExecHook(func(ctx context.Context, event *CobraLambdaEvent) {
+		called = true
+	}))
+
+	if _, err := handler(context.Background(), json.RawMessage(`{"args": [invalid`)); err == nil {
+		t.Fatal("Expected error for invalid JSON, got nil")
+	}
+	if called {
+		t.Error("Pre-exec hook should not be called when unmarshaling fails")
+	}
+}
+
+func TestNewCobrLambdaHandler_PostExecHookSuccess(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			time.Sleep(20 * time.Millisecond)
+			cmd.Println("done")
+		},
+	}
+
+	calls := 0
+	var hookOutput *CobraLambdaOutput
+	var hookErr error
+	var hookElapsed time.Duration
+
+	handler := NewCobrLambdaHandler(cmd, WithPostExecHook(func(ctx context.Context, event *CobraLambdaEvent, output *CobraLambdaOutput, err error, elapsed time.Duration) {
+		calls++
+		hookOutput = output
+		hookErr = err
+		hookElapsed = elapsed
+	}))
+
+	if _, err := handler(context.Background(), json.RawMessage(`{"args": []}`)); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("Expected post-exec hook to be called once, got %d", calls)
+	}
+	if hookErr != nil {
+		t.Errorf("Expected nil error, got: %v", hookErr)
+	}
+	if hookOutput == nil || !strings.Contains(hookOutput.Stdout, "done") {
+		t.Errorf("Expected hook to receive output, got: %+v", hookOutput)
+	}
+	if hookElapsed < 20*time.Millisecond || hookElapsed > 5*time.Second {
+		t.Errorf("Implausible duration: %v", hookElapsed)
+	}
+}
+
+func TestNewCobrLambdaHandler_PostExecHookError(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return errors.New("command failed")
+		},
+	}
+
+	calls := 0
+	var hookErr error
+
+	handler := NewCobrLambdaHandler(cmd, WithPostExecHook(func(ctx context.Context, event *CobraLambdaEvent, output *CobraLambdaOutput, err error, elapsed time.Duration) {
+		calls++
+		hookErr = err
+	}))
+
+	if _, err := handler(context.Background(), json.RawMessage(`{"args": []}`)); err == nil {
+		t.Fatal("Expected error from command, got nil")
+	}
+
+	if calls != 1 {
+		t.Fatalf("Expected post-exec hook to be called once, got %d", calls)
+	}
+	if hookErr == nil || hookErr.Error() != "command failed" {
+		t.Errorf("Expected 'command failed', got: %v", hookErr)
+	}
+}
+
+func TestNewCobrLambdaHandler_PostExecHookPanic(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			panic("boom")
+		},
+	}
+
+	calls := 0
+	var hookErr error
+
+	handler := NewCobrLambdaHandler(cmd, WithPostExecHook(func(ctx context.Context, event *CobraLambdaEvent, output *CobraLambdaOutput, err error, elapsed time.Duration) {
+		calls++
+		hookErr = err
+	}))
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected panic to be re-raised")
+			}
+		}()
+		_, _ = handler(context.Background(), json.RawMessage(`{"args": []}`))
+	}()
+
+	if calls != 1 {
+		t.Fatalf("Expected post-exec hook to be called once, got %d", calls)
+	}
+	if hookErr == nil || !strings.Contains(hookErr.Error(), "boom") {
+		t.Errorf("Expected panic error, got: %v", hookErr)
+	}
+}
+
+func TestClientContext(t *testing.T) {
+	var custom map[string]string
+	var found bool
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			var cc lambdacontext.ClientContext
+			cc, found = ClientContext(cmd.Context())
+			custom = cc.Custom
+		},
+	}
+
+	lc := &lambdacontext.LambdaContext{
+		ClientContext: lambdacontext.ClientContext{
+			Custom: map[string]string{"tenant": "acme"},
+		},
+	}
+	ctx := lambdacontext.NewContext(context.Background(), lc)
+
+	if _, err := NewCobrLambdaHandler(cmd)(ctx, json.RawMessage(`{"args": []}`)); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if !found || custom["tenant"] != "acme" {
+		t.Errorf("Expected client context with tenant acme, got: %v (found %v)", custom, found)
+	}
+
+	if _, ok := ClientContext(context.Background()); ok {
+		t.Error("Expected no client context without a Lambda context")
+	}
+}
+
+func TestNewCobrLambdaHandler_RawEvent(t *testing.T) {
+	var raw json.RawMessage
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			raw = RawEvent(cmd.Context())
+		},
+	}
+
+	eventJSON := json.RawMessage(`{"args":[],"metadata":{"tenant":"acme"}}`)
+	if _, err := NewCobrLambdaHandler(cmd, WithoutMirror())(context.TODO(), eventJSON); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if string(raw) != string(eventJSON) {
+		t.Errorf("Expected the raw event, got: %s", raw)
+	}
+
+	if RawEvent(context.Background()) != nil {
+		t.Error("Expected no raw event outside a handler")
+	}
+}
+
+type spanKey struct{}
+
+func TestNewTypedHandler_ContextFactory(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			span, _ := cmd.Context().Value(spanKey{}).(string)
+			cmd.Printf("span=%s correlation=%s", span, EventContext(cmd.Context())["correlation_id"])
+		},
+	}
+
+	factory := func(parent context.Context, event *CobraLambdaEvent) context.Context {
+		// the factory cannot change the args the command runs with
+		event.Args = []string{"changed"}
+		return context.WithValue(parent, spanKey{}, "span-"+event.Context["correlation_id"])
+	}
+
+	handler := NewTypedHandler(cmd, WithoutMirror(), WithContextFactory(factory))
+
+	output, err := handler(context.Background(), CobraLambdaEvent{
+		Args:    []string{},
+		Context: map[string]string{"correlation_id": "abc"},
+	})
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+
+	if output.Stdout != "span=span-abc correlation=abc" {
+		t.Errorf("Expected the command to see the factory's value and the event context, got: %q", output.Stdout)
+	}
+}
+
+func TestNewTypedHandler_ContextFactoryNil(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Print(RequestID(cmd.Context()))
+		},
+	}
+
+	handler := NewTypedHandler(cmd, WithoutMirror(), WithContextFactory(func(context.Context, *CobraLambdaEvent) context.Context {
+		return nil
+	}))
+
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "req-1"})
+	output, err := handler(ctx, CobraLambdaEvent{})
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+	if output.Stdout != "req-1" {
+		t.Errorf("Expected the parent context when the factory returns nil, got: %q", output.Stdout)
+	}
+}
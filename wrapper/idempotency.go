@@ -0,0 +1,40 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+)
+
+// IdempotencyStore persists the output of successful executions by idempotency
+// key so duplicate deliveries of the same event can be answered without running
+// the command again
+type IdempotencyStore interface {
+	// Get returns the output stored for key, ok is false when there is none
+	Get(ctx context.Context, key string) (output *CobraLambdaOutput, ok bool, err error)
+	// Put stores the output of a successful execution under key
+	Put(ctx context.Context, key string, output *CobraLambdaOutput) error
+}
+
+// WithIdempotency makes handlers look up events carrying an IdempotencyKey in
+// store before executing. Events whose key was seen before return the stored
+// output instead of re-running the command, events without a key always run.
+// Only successful executions are stored so failed events can be retried
+func WithIdempotency(store IdempotencyStore) Option {
+	return func(w *CobraLambda) {
+		w.idempotencyStore = store
+	}
+}
+
+// cachedOutput returns a copy of the output stored for key, if any
+func (w *CobraLambda) cachedOutput(ctx context.Context, key string) (*CobraLambdaOutput, bool, error) {
+	output, ok, err := w.idempotencyStore.Get(ctx, key)
+	if err != nil {
+		return nil, false, fmt.Errorf("wrapper: idempotency lookup: %w", err)
+	}
+	if !ok || output == nil {
+		return nil, false, nil
+	}
+
+	cached := *output
+	return &cached, true, nil
+}
@@ -0,0 +1,186 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/spf13/cobra"
+)
+
+// memoryStore is an in-memory IdempotencyStore
+type memoryStore struct {
+	mu      sync.Mutex
+	outputs map[string]CobraLambdaOutput
+	getErr  error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{outputs: map[string]CobraLambdaOutput{}}
+}
+
+func (m *memoryStore) Get(ctx context.Context, key string) (*CobraLambdaOutput, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.getErr != nil {
+		return nil, false, m.getErr
+	}
+
+	output, ok := m.outputs[key]
+	return &output, ok, nil
+}
+
+func (m *memoryStore) Put(ctx context.Context, key string, output *CobraLambdaOutput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.outputs[key] = *output
+	return nil
+}
+
+func TestNewCobrLambdaHandler_IdempotencyDuplicate(t *testing.T) {
+	runs := 0
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			runs++
+			cmd.Printf("charged %s\n", strings.Join(args, " "))
+		},
+	}
+
+	store := newMemoryStore()
+	handler := NewTypedHandler(cmd, WithIdempotency(store))
+	event := CobraLambdaEvent{Args: []string{"order-1"}, IdempotencyKey: "delivery-1"}
+
+	first, err := handler(context.Background(), event)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	second, err := handler(context.Background(), event)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if runs != 1 {
+		t.Errorf("Expected command to run once, ran %d times", runs)
+	}
+	if second.Stdout != first.Stdout || !strings.Contains(second.Stdout, "charged order-1") {
+		t.Errorf("Expected cached output %q, got: %q", first.Stdout, second.Stdout)
+	}
+
+	if _, err := handler(context.Background(), CobraLambdaEvent{Args: []string{"order-2"}, IdempotencyKey: "delivery-2"}); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if runs != 2 {
+		t.Errorf("Expected a different event to run the command, ran %d times", runs)
+	}
+}
+
+func TestNewCobrLambdaHandler_IdempotencySkipsFailures(t *testing.T) {
+	runs := 0
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runs++
+			return errFailed
+		},
+	}
+
+	store := newMemoryStore()
+	handler := NewTypedHandler(cmd, WithIdempotency(store))
+
+	for i := 0; i < 2; i++ {
+		if _, err := handler(context.Background(), CobraLambdaEvent{IdempotencyKey: "delivery-1"}); !errors.Is(err, errFailed) {
+			t.Fatalf("Expected command error, got: %v", err)
+		}
+	}
+
+	if runs != 2 {
+		t.Errorf("Expected failed events to be retried, ran %d times", runs)
+	}
+	if len(store.outputs) != 0 {
+		t.Errorf("Expected no stored outputs, got: %v", store.outputs)
+	}
+}
+
+func TestNewCobrLambdaHandler_IdempotencyLookupError(t *testing.T) {
+	ran := false
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			ran = true
+		},
+	}
+
+	store := newMemoryStore()
+	store.getErr = errors.New("store unavailable")
+
+	_, err := NewTypedHandler(cmd, WithIdempotency(store))(context.Background(), CobraLambdaEvent{IdempotencyKey: "delivery-1"})
+	if err == nil || !strings.Contains(err.Error(), "store unavailable") {
+		t.Fatalf("Expected lookup error, got: %v", err)
+	}
+	if ran {
+		t.Error("Expected command not to run when the store lookup fails")
+	}
+}
+
+func TestNewCobrLambdaHandler_IdempotencyWithoutKey(t *testing.T) {
+	runs := 0
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			runs++
+		},
+	}
+
+	store := newMemoryStore()
+	handler := NewTypedHandler(cmd, WithIdempotency(store))
+
+	// separate invocations with the same args are not duplicates
+	for i := 0; i < 2; i++ {
+		if _, err := handler(context.Background(), CobraLambdaEvent{Args: []string{"order-1"}}); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+	}
+
+	if runs != 2 {
+		t.Errorf("Expected events without a key to run every time, ran %d times", runs)
+	}
+	if len(store.outputs) != 0 {
+		t.Errorf("Expected no stored outputs, got: %v", store.outputs)
+	}
+}
+
+func TestNewSQSHandler_IdempotencyByMessageID(t *testing.T) {
+	runs := 0
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			runs++
+		},
+	}
+
+	handler := NewSQSHandler(cmd, WithoutMirror(), WithIdempotency(newMemoryStore()))
+	body := `{"args": ["order-1"]}`
+
+	// msg-1 is delivered twice, msg-2 carries the same args as a separate message
+	for _, ids := range [][]string{{"msg-1"}, {"msg-1", "msg-2"}} {
+		event := events.SQSEvent{}
+		for _, id := range ids {
+			event.Records = append(event.Records, sqsRecord(id, body, ""))
+		}
+
+		if _, err := handler(context.Background(), event); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+	}
+
+	if runs != 2 {
+		t.Errorf("Expected the command to run once per message, ran %d times", runs)
+	}
+}
@@ -0,0 +1,84 @@
+package wrapper
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// CommandInfo describes a command and its flags, for example to render a form
+// for each command in a UI
+type CommandInfo struct {
+	Name     string        `json:"name"`
+	Path     string        `json:"path"`
+	Short    string        `json:"short,omitempty"`
+	Runnable bool          `json:"runnable"`
+	Flags    []FlagInfo    `json:"flags"`
+	Commands []CommandInfo `json:"commands,omitempty"`
+}
+
+// FlagInfo describes a single flag accepted by a command
+type FlagInfo struct {
+	Name      string `json:"name"`
+	Shorthand string `json:"shorthand,omitempty"`
+	// Type is the pflag value type such as "string", "int" or "stringSlice"
+	Type     string `json:"type"`
+	Default  string `json:"default"`
+	Required bool   `json:"required"`
+	Usage    string `json:"usage"`
+	// Persistent is set for flags that also apply to subcommands
+	Persistent bool `json:"persistent"`
+	// Inherited is set for persistent flags declared on a parent command
+	Inherited bool `json:"inherited"`
+}
+
+// Describe returns a description of cmd and every visible subcommand along with
+// the flags each accepts, including persistent flags inherited from parents.
+// Hidden commands and flags are left out
+func Describe(cmd *cobra.Command) CommandInfo {
+	info := CommandInfo{
+		Name:     cmd.Name(),
+		Path:     cmd.CommandPath(),
+		Short:    cmd.Short,
+		Runnable: cmd.Runnable(),
+		Flags:    []FlagInfo{},
+	}
+
+	persistent := cmd.PersistentFlags()
+	cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		if !f.Hidden {
+			info.Flags = append(info.Flags, describeFlag(f, persistent.Lookup(f.Name) != nil, false))
+		}
+	})
+	cmd.InheritedFlags().VisitAll(func(f *pflag.Flag) {
+		if !f.Hidden {
+			info.Flags = append(info.Flags, describeFlag(f, true, true))
+		}
+	})
+
+	for _, sub := range cmd.Commands() {
+		if sub.Hidden {
+			continue
+		}
+		info.Commands = append(info.Commands, Describe(sub))
+	}
+
+	return info
+}
+
+func describeFlag(f *pflag.Flag, persistent, inherited bool) FlagInfo {
+	required := false
+	if values, ok := f.Annotations[cobra.BashCompOneRequiredFlag]; ok && len(values) > 0 && values[0] == "true" {
+		required = true
+	}
+
+	return FlagInfo{
+		Name:       f.Name,
+		Shorthand:  f.Shorthand,
+		Type:       f.Value.Type(),
+		Default:    f.DefValue,
+		Required:   required,
+		Usage:      f.Usage,
+		Persistent: persistent,
+		Inherited:  inherited,
+	}
+}
@@ -0,0 +1,107 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func findFlag(flags []FlagInfo, name string) (FlagInfo, bool) {
+	for _, f := range flags {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return FlagInfo{}, false
+}
+
+func TestDescribe_Flags(t *testing.T) {
+	root := &cobra.Command{Use: "root", Short: "Root command"}
+	root.PersistentFlags().StringP("region", "r", "us-east-1", "AWS region")
+
+	deployCmd := &cobra.Command{
+		Use:   "deploy",
+		Short: "Deploy a stack",
+		Run:   func(cmd *cobra.Command, args []string) {},
+	}
+	deployCmd.Flags().String("stack", "default", "Stack name")
+	deployCmd.Flags().Int("replicas", 0, "Number of replicas")
+	_ = deployCmd.MarkFlagRequired("replicas")
+	deployCmd.Flags().Bool("secret", false, "Hidden flag")
+	_ = deployCmd.Flags().MarkHidden("secret")
+
+	root.AddCommand(deployCmd, &cobra.Command{Use: "internal", Hidden: true, Run: func(cmd *cobra.Command, args []string) {}})
+
+	info := Describe(root)
+
+	if info.Path != "root" || info.Runnable {
+		t.Errorf("Unexpected root description: %+v", info)
+	}
+	if len(info.Commands) != 1 {
+		t.Fatalf("Expected only the visible subcommand, got: %+v", info.Commands)
+	}
+
+	deploy := info.Commands[0]
+	if deploy.Path != "root deploy" || deploy.Short != "Deploy a stack" || !deploy.Runnable {
+		t.Errorf("Unexpected deploy description: %+v", deploy)
+	}
+
+	stack, ok := findFlag(deploy.Flags, "stack")
+	if !ok {
+		t.Fatalf("Expected stack flag, got: %+v", deploy.Flags)
+	}
+	if stack.Type != "string" || stack.Default != "default" || stack.Required || stack.Usage != "Stack name" || stack.Persistent {
+		t.Errorf("Unexpected stack flag: %+v", stack)
+	}
+
+	replicas, ok := findFlag(deploy.Flags, "replicas")
+	if !ok {
+		t.Fatalf("Expected replicas flag, got: %+v", deploy.Flags)
+	}
+	if replicas.Type != "int" || replicas.Default != "0" || !replicas.Required {
+		t.Errorf("Unexpected replicas flag: %+v", replicas)
+	}
+
+	region, ok := findFlag(deploy.Flags, "region")
+	if !ok {
+		t.Fatalf("Expected inherited region flag, got: %+v", deploy.Flags)
+	}
+	if region.Shorthand != "r" || region.Default != "us-east-1" || !region.Persistent || !region.Inherited {
+		t.Errorf("Unexpected region flag: %+v", region)
+	}
+
+	if _, ok := findFlag(deploy.Flags, "secret"); ok {
+		t.Error("Expected hidden flag to be left out")
+	}
+
+	rootRegion, ok := findFlag(info.Flags, "region")
+	if !ok || !rootRegion.Persistent || rootRegion.Inherited {
+		t.Errorf("Expected region to be a persistent flag declared on root, got: %+v", rootRegion)
+	}
+}
+
+func TestDescribe_JSON(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	root.PersistentFlags().StringP("region", "r", "us-east-1", "AWS region")
+
+	deploy := &cobra.Command{
+		Use: "deploy",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
+	deploy.Flags().Int("replicas", 0, "Number of replicas")
+	_ = deploy.MarkFlagRequired("replicas")
+	root.AddCommand(deploy)
+
+	encoded, err := json.Marshal(Describe(root))
+	if err != nil {
+		t.Fatalf("Failed to marshal description: %v", err)
+	}
+
+	for _, expected := range []string{`"name":"replicas"`, `"type":"int"`, `"required":true`, `"shorthand":"r"`} {
+		if !strings.Contains(string(encoded), expected) {
+			t.Errorf("Expected %s in %s", expected, encoded)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package wrapper
+
+import "errors"
+
+// ErrCommandPanicked is wrapped by the error Execute returns when the command
+// panicked and the wrapper is configured with WithIsolatedExecution
+var ErrCommandPanicked = errors.New("wrapper: command panicked")
+
+// ErrCommandExited is returned by Execute when the command goroutine stopped
+// without returning, for example by calling runtime.Goexit
+var ErrCommandExited = errors.New("wrapper: command exited without returning")
+
+// WithIsolatedExecution keeps a panic inside the command from unwinding the
+// caller. Execute instead returns the output captured so far with exit code 1 and
+// an error wrapping ErrCommandPanicked
+func WithIsolatedExecution() Option {
+	return func(w *CobraLambda) {
+		w.isolatedExecution = true
+	}
+}
@@ -0,0 +1,88 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_IsolatedExecutionPanic(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("before panic")
+			panic("boom")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.Background(), cmd, WithIsolatedExecution())
+	output, err := wrapper.Execute([]string{})
+
+	if !errors.Is(err, ErrCommandPanicked) {
+		t.Fatalf("Expected ErrCommandPanicked, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected error to include the panic value, got: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "before panic") {
+		t.Errorf("Expected partial output to be returned, got: %s", output.Stdout)
+	}
+	if output.ExitCode != 1 {
+		t.Errorf("Expected exit code 1, got: %d", output.ExitCode)
+	}
+
+	// the wrapper remains usable after a crash
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		fmt.Println("recovered")
+	}
+	output, err = wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "recovered") {
+		t.Errorf("Got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_IsolatedExecutionGoexit(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("before exit")
+			runtime.Goexit()
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.Background(), cmd, WithIsolatedExecution())
+	output, err := wrapper.Execute([]string{})
+
+	if !errors.Is(err, ErrCommandExited) {
+		t.Fatalf("Expected ErrCommandExited, got: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "before exit") {
+		t.Errorf("Expected partial output to be returned, got: %s", output.Stdout)
+	}
+}
+
+func TestNewCobrLambdaHandler_IsolatedExecutionPanic(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			panic("handler boom")
+		},
+	}
+
+	output, err := NewTypedHandler(cmd, WithIsolatedExecution())(context.Background(), CobraLambdaEvent{})
+
+	if !errors.Is(err, ErrCommandPanicked) {
+		t.Fatalf("Expected ErrCommandPanicked, got: %v", err)
+	}
+	if output == nil || output.ExitCode != 1 {
+		t.Errorf("Expected output with exit code 1, got: %+v", output)
+	}
+}
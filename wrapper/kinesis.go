@@ -0,0 +1,76 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/spf13/cobra"
+)
+
+// KinesisMapper converts the decoded data of a Kinesis record into the
+// arguments to run the command with
+type KinesisMapper func(data []byte) ([]string, error)
+
+// ErrNilMapper is returned by Kinesis handlers constructed with a nil mapper
+var ErrNilMapper = errors.New("wrapper: kinesis mapper must not be nil")
+
+// KinesisOutput aggregates the results of every record alongside the sequence
+// number of the first failed record, reported as a partial batch response so
+// Lambda checkpoints before it and retries from there
+type KinesisOutput struct {
+	BatchOutput
+	BatchItemFailures []events.KinesisBatchItemFailure `json:"batchItemFailures"`
+}
+
+type KinesisFunc func(ctx context.Context, event events.KinesisEvent) (*KinesisOutput, error)
+
+// NewKinesisHandler returns a handler that runs cmd once per Kinesis record with
+// the arguments mapFn derives from the record data. Records of a shard must be
+// processed in order, so the batch stops at the first record whose mapping or
+// execution fails and that record is reported in BatchItemFailures, later
+// records are left for Lambda to retry. Flags are reset between records
+func NewKinesisHandler(cmd *cobra.Command, mapFn KinesisMapper, opts ...Option) KinesisFunc {
+	return func(ctx context.Context, event events.KinesisEvent) (*KinesisOutput, error) {
+		if cmd == nil {
+			return nil, ErrNilCommand
+		}
+
+		if mapFn == nil {
+			return nil, ErrNilMapper
+		}
+
+		lambda := newCobraLambda(ctx, cmd, opts...)
+
+		output := &KinesisOutput{
+			BatchOutput: BatchOutput{
+				Results: make([]RecordResult, 0, len(event.Records)),
+			},
+			BatchItemFailures: []events.KinesisBatchItemFailure{},
+		}
+
+		for _, record := range event.Records {
+			sequenceNumber := record.Kinesis.SequenceNumber
+
+			var result RecordResult
+			args, err := mapFn(record.Kinesis.Data)
+			if err != nil {
+				result = RecordResult{ID: sequenceNumber, Error: err.Error()}
+			} else {
+				recordEvent := EventFromArgs(args)
+				result = lambda.executeRecordEvent(ctx, sequenceNumber, &recordEvent)
+			}
+
+			output.Results = append(output.Results, result)
+
+			if result.Error != "" {
+				output.BatchItemFailures = append(output.BatchItemFailures, events.KinesisBatchItemFailure{
+					ItemIdentifier: sequenceNumber,
+				})
+				break
+			}
+		}
+
+		return output, nil
+	}
+}
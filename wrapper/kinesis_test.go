@@ -0,0 +1,132 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/spf13/cobra"
+)
+
+// csvMapper splits record data on commas, rejecting empty records
+func csvMapper(data []byte) ([]string, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty record")
+	}
+	return strings.Split(string(data), ","), nil
+}
+
+func TestNewKinesisHandler_Records(t *testing.T) {
+	var name string
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if name == "fail" {
+				return errFailed
+			}
+			cmd.Printf("Hello, %s!\n", name)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "World", "Name to greet")
+
+	// data is base64 encoded on the wire and decoded when the event is unmarshaled
+	payload := `{"Records": [
+		{"kinesis": {"sequenceNumber": "1", "data": "LS1uYW1lLEFsaWNl"}},
+		{"kinesis": {"sequenceNumber": "2", "data": ""}},
+		{"kinesis": {"sequenceNumber": "3", "data": "LS1uYW1lLGZhaWw="}},
+		{"kinesis": {"sequenceNumber": "4", "data": "LS1uYW1lLEJvYg=="}}
+	]}`
+
+	var event events.KinesisEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		t.Fatalf("Failed to unmarshal event: %v", err)
+	}
+
+	output, err := NewKinesisHandler(cmd, csvMapper)(context.Background(), event)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	// record 2 fails, so processing stops there and record 3 is left for the retry
+	if len(output.Results) != 2 {
+		t.Fatalf("Expected processing to stop at the first failure, got %d results", len(output.Results))
+	}
+
+	if first := output.Results[0]; first.ID != "1" || first.Output == nil || !strings.Contains(first.Output.Stdout, "Hello, Alice!") {
+		t.Errorf("Unexpected first result: %+v", first)
+	}
+	if second := output.Results[1]; second.Error != "empty record" || second.Output != nil {
+		t.Errorf("Expected mapper error on second result, got: %+v", second)
+	}
+
+	if len(output.BatchItemFailures) != 1 || output.BatchItemFailures[0].ItemIdentifier != "2" {
+		t.Errorf("Expected only record 2 to be reported, got: %v", output.BatchItemFailures)
+	}
+
+	// the retried batch starts at the failed record
+	event.Records = event.Records[2:]
+	output, err = NewKinesisHandler(cmd, csvMapper)(context.Background(), event)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if len(output.Results) != 1 || output.Results[0].Error != errFailed.Error() {
+		t.Errorf("Expected command error on the first retried record, got: %+v", output.Results)
+	}
+	if len(output.BatchItemFailures) != 1 || output.BatchItemFailures[0].ItemIdentifier != "3" {
+		t.Errorf("Expected record 3 to be reported, got: %v", output.BatchItemFailures)
+	}
+}
+
+func TestNewKinesisHandler_NilMapper(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
+
+	event := events.KinesisEvent{
+		Records: []events.KinesisEventRecord{
+			{Kinesis: events.KinesisRecord{SequenceNumber: "1", Data: []byte("a")}},
+		},
+	}
+
+	if _, err := NewKinesisHandler(cmd, nil)(context.Background(), event); !errors.Is(err, ErrNilMapper) {
+		t.Errorf("Expected ErrNilMapper, got: %v", err)
+	}
+}
+
+func TestNewKinesisHandler_NoFailures(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Println(strings.Join(args, " "))
+		},
+	}
+
+	event := events.KinesisEvent{
+		Records: []events.KinesisEventRecord{
+			{Kinesis: events.KinesisRecord{SequenceNumber: "1", Data: []byte("a,b")}},
+		},
+	}
+
+	output, err := NewKinesisHandler(cmd, csvMapper)(context.Background(), event)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	encoded, err := json.Marshal(output)
+	if err != nil {
+		t.Fatalf("Failed to marshal output: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"batchItemFailures":[]`) {
+		t.Errorf("Expected an empty failure list, got: %s", encoded)
+	}
+	if !strings.Contains(output.Results[0].Output.Stdout, "a b") {
+		t.Errorf("Got: %s", output.Results[0].Output.Stdout)
+	}
+}
@@ -1,46 +1,213 @@
 package wrapper
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
-	"os"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/spf13/cobra"
 )
 
 type CobraLambdaEvent struct {
 	Args []string `json:"args"`
+	// Path optionally names the subcommand to run, e.g. ["db", "migrate"]
+	Path []string `json:"path,omitempty"`
+	// Flags optionally holds flag values by name, assembled into the invocation
+	// after Path and before Args
+	Flags map[string]any `json:"flags,omitempty"`
+	// Stdin optionally holds input for the command, read with cmd.InOrStdin() or
+	// os.Stdin. Without it os.Stdin is empty rather than the runtime's stdin
+	Stdin string `json:"stdin,omitempty"`
+	// Context optionally holds values such as correlation IDs made available to
+	// the command with EventContext
+	Context map[string]string `json:"context,omitempty"`
+	// ResponseFormat optionally asks NewCobrLambdaHandler for a response other
+	// than JSON, see ResponseFormatMsgpack
+	ResponseFormat string `json:"responseFormat,omitempty"`
+	// Confirm allows commands annotated with ConfirmationAnnotation to run
+	Confirm bool `json:"confirm,omitempty"`
+	// Format optionally asks the command for an output format such as "json",
+	// passed to it with the flag named by WithFormatFlag
+	Format string `json:"format,omitempty"`
+	// IdempotencyKey optionally identifies the event for WithIdempotency, events
+	// with the same key run the command once. Records of SQS, SNS and Kinesis
+	// events default to their message ID or sequence number
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
 }
 
 type CobraLambdaFunc func(ctx context.Context, event json.RawMessage) (any, error)
 
-func NewCobrLambdaHandler(cmd *cobra.Command) CobraLambdaFunc {
-	return func(ctx context.Context, eventJSON json.RawMessage) (any, error) {
-		lambda := &CobraLambda{
-			cmd:            cmd,
-			ctx:            ctx,
-			originalStdout: os.Stdout,
-			originalStderr: os.Stderr,
-		}
+// CobraLambdaTypedFunc is a handler taking a decoded event and returning concrete
+// output, usable directly with lambda.Start without type assertions
+type CobraLambdaTypedFunc func(ctx context.Context, event CobraLambdaEvent) (*CobraLambdaOutput, error)
 
+func NewCobrLambdaHandler(cmd *cobra.Command, opts ...Option) CobraLambdaFunc {
+	return func(ctx context.Context, eventJSON json.RawMessage) (any, error) {
 		event, err := UnmarshalEvent(eventJSON)
 
 		if err != nil {
 			return nil, err
 		}
 
-		return lambda.ExecuteContext(ctx, event.Args)
+		if err := checkResponseFormat(event.ResponseFormat); err != nil {
+			return nil, err
+		}
+
+		if cmd == nil {
+			return nil, ErrNilCommand
+		}
+
+		lambda := newCobraLambda(ctx, cmd, opts...)
+
+		output, err := lambda.handle(withRawEvent(ctx, eventJSON), event)
+		if output == nil {
+			// avoid returning a typed nil inside the interface
+			return nil, err
+		}
+
+		if event.ResponseFormat == ResponseFormatMsgpack {
+			response, encodeErr := EncodeResponse(output, event.ResponseFormat)
+			if encodeErr != nil {
+				return nil, encodeErr
+			}
+			return response, err
+		}
+
+		if lambda.responseEncoder != nil {
+			return lambda.responseEncoder(ctx, output), err
+		}
+
+		return output, err
+	}
+}
+
+// NewTypedHandler is like NewCobrLambdaHandler but accepts an already decoded
+// event and returns *CobraLambdaOutput instead of any
+func NewTypedHandler(cmd *cobra.Command, opts ...Option) CobraLambdaTypedFunc {
+	return func(ctx context.Context, event CobraLambdaEvent) (*CobraLambdaOutput, error) {
+		if cmd == nil {
+			return nil, ErrNilCommand
+		}
+
+		lambda := newCobraLambda(ctx, cmd, opts...)
+
+		return lambda.handle(ctx, &event)
 	}
 }
 
+// handle runs the command for a single invocation with its hooks
+func (w *CobraLambda) handle(ctx context.Context, event *CobraLambdaEvent) (*CobraLambdaOutput, error) {
+	w.runPreExecHooks(ctx, event)
+
+	return w.executeWithHooks(ctx, event)
+}
+
+// Start runs cmd as the Lambda function, serving each invocation with the
+// handler from NewCobrLambdaHandler so events can ask for MessagePack responses
+// and options such as WithEnvelopeV1 apply. Like lambda.Start it does not return
+func Start(cmd *cobra.Command, opts ...Option) {
+	lambda.Start(NewCobrLambdaHandler(cmd, opts...))
+}
+
+// RemainingTime reports how long is left before the invocation deadline carried
+// by ctx, as set by the Lambda runtime from the invoke request or by cldebug from
+// its --timeout flag. Commands can call it with cmd.Context() to size their work.
+// ok is false when ctx has no deadline
+func RemainingTime(ctx context.Context) (remaining time.Duration, ok bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+// UnmarshalEvent decodes eventJSON into a CobraLambdaEvent. Empty payloads, null
+// and {} are treated as an event without arguments so warmers and bare invokes
+// run the root command. Syntax and type errors are wrapped with the byte offset
+// they occurred at and the input around it
 func UnmarshalEvent(eventJSON json.RawMessage) (*CobraLambdaEvent, error) {
 	event := &CobraLambdaEvent{}
 
-	err := json.Unmarshal(eventJSON, event)
+	if len(bytes.TrimSpace(eventJSON)) > 0 {
+		err := json.Unmarshal(eventJSON, event)
 
-	if err != nil {
-		return nil, err
+		if err != nil {
+			return nil, decodeError(eventJSON, err)
+		}
+	}
+
+	if event.Args == nil {
+		event.Args = []string{}
 	}
 
 	return event, nil
 }
+
+// decodeSnippetRadius is how many bytes of input either side of a decode error
+// are included in the error
+const decodeSnippetRadius = 20
+
+// decodeError adds the offset and surrounding input of a JSON syntax or type
+// error to err, which stays available to errors.As
+func decodeError(input []byte, err error) error {
+	var offset int64
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return err
+	}
+
+	start := max(offset-decodeSnippetRadius, 0)
+	end := min(offset+decodeSnippetRadius, int64(len(input)))
+
+	return fmt.Errorf("wrapper: invalid event at byte %d near %q: %w", offset, input[start:end], err)
+}
+
+// EventFromArgs builds the event for args as taken from the command line, copying
+// them so later changes to the slice don't leak into the event. nil args become an
+// empty slice so the remote cli never falls back to its own os.Args
+func EventFromArgs(args []string) CobraLambdaEvent {
+	return CobraLambdaEvent{
+		Args: append([]string{}, args...),
+	}
+}
+
+// clone returns a deep copy of the event
+func (e *CobraLambdaEvent) clone() *CobraLambdaEvent {
+	clone := &CobraLambdaEvent{
+		Args:  append([]string(nil), e.Args...),
+		Path:  append([]string(nil), e.Path...),
+		Stdin: e.Stdin,
+
+		ResponseFormat: e.ResponseFormat,
+		Confirm:        e.Confirm,
+		Format:         e.Format,
+		IdempotencyKey: e.IdempotencyKey,
+	}
+
+	if e.Flags != nil {
+		clone.Flags = make(map[string]any, len(e.Flags))
+		for name, value := range e.Flags {
+			clone.Flags[name] = value
+		}
+	}
+
+	if e.Context != nil {
+		clone.Context = make(map[string]string, len(e.Context))
+		for key, value := range e.Context {
+			clone.Context[key] = value
+		}
+	}
+
+	return clone
+}
@@ -3,10 +3,14 @@ package wrapper
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-lambda-go/events"
 	"github.com/spf13/cobra"
 )
 
@@ -270,3 +274,379 @@ func TestNewCobrLambdaHandler_ContextPropagation(t *testing.T) {
 		t.Errorf("Expected context propagation confirmation, got: %s", output.Stdout)
 	}
 }
+
+func TestNewCobrLambdaHandler_DeadlinePropagation(t *testing.T) {
+	var got time.Time
+	var gotOK bool
+	var remaining time.Duration
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			got, gotOK = cmd.Context().Deadline()
+			remaining, _ = RemainingTime(cmd.Context())
+		},
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	if _, err := NewCobrLambdaHandler(cmd)(ctx, json.RawMessage(`{"args":[]}`)); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if !gotOK || !got.Equal(deadline) {
+		t.Errorf("Expected command deadline %v, got %v (ok %v)", deadline, got, gotOK)
+	}
+
+	if remaining <= 0 || remaining > 5*time.Second {
+		t.Errorf("Expected remaining time within 5s, got %v", remaining)
+	}
+}
+
+func TestNewCobrLambdaHandler_AbandonedInvocationHoldsOffNext(t *testing.T) {
+	var active, overlaps atomic.Int32
+	var name string
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			if active.Add(1) > 1 {
+				overlaps.Add(1)
+			}
+			defer active.Add(-1)
+
+			fmt.Printf("name=%s\n", name)
+			// ignores the cancelled context like a blocking call would
+			time.Sleep(400 * time.Millisecond)
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "default", "")
+
+	handler := NewTypedHandler(cmd, WithoutMirror(), WithDeadlineMargin(50*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	first, err := handler(ctx, CobraLambdaEvent{Args: []string{"--name", "first"}})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if !first.TimedOut {
+		t.Fatal("Expected the first invocation to time out")
+	}
+
+	// a warm container runs the next invocation on the same tree
+	second, err := handler(context.Background(), CobraLambdaEvent{Args: []string{}})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if n := overlaps.Load(); n != 0 {
+		t.Errorf("Expected the invocations not to overlap, overlapped %d times", n)
+	}
+	if second.Stdout != "name=default\n" {
+		t.Errorf("Expected flags reset for the second invocation, got: %q", second.Stdout)
+	}
+}
+
+func TestRemainingTime_NoDeadline(t *testing.T) {
+	if remaining, ok := RemainingTime(context.Background()); ok {
+		t.Errorf("Expected no deadline, got %v remaining", remaining)
+	}
+}
+
+func TestNewTypedHandler_BasicExecution(t *testing.T) {
+	var name string
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Printf("Hello, %s!\n", name)
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "World", "Name to greet")
+
+	handler := NewTypedHandler(cmd)
+
+	output, err := handler(context.Background(), CobraLambdaEvent{
+		Args: []string{"--name", "Typed"},
+	})
+
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if !strings.Contains(output.Stdout, "Hello, Typed!") {
+		t.Errorf("Expected output to contain 'Hello, Typed!', got: %s", output.Stdout)
+	}
+}
+
+func TestNewTypedHandler_CommandError(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.Println("About to fail")
+			return fmt.Errorf("command execution failed")
+		},
+	}
+
+	handler := NewTypedHandler(cmd)
+
+	output, err := handler(context.Background(), CobraLambdaEvent{})
+
+	if err == nil || err.Error() != "command execution failed" {
+		t.Fatalf("Expected 'command execution failed', got: %v", err)
+	}
+
+	if output == nil || !strings.Contains(output.Stdout, "About to fail") {
+		t.Errorf("Expected output to be captured before error, got: %+v", output)
+	}
+}
+
+func TestNewCobrLambdaHandler_EchoArgs(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Println("ran")
+		},
+	}
+
+	eventJSON := json.RawMessage(`{"args": ["one", "two words", "--flag"]}`)
+	cmd.Flags().Bool("flag", false, "A flag")
+
+	result, err := NewCobrLambdaHandler(cmd, WithEchoArgs())(context.Background(), eventJSON)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	output := result.(*CobraLambdaOutput)
+	expected := []string{"one", "two words", "--flag"}
+	if strings.Join(output.Args, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected args %v, got: %v", expected, output.Args)
+	}
+	if strings.Contains(output.Stdout, "two words") {
+		t.Errorf("Expected args not to be written to Stdout, got: %s", output.Stdout)
+	}
+
+	// Without the option the field stays empty
+	result, err = NewCobrLambdaHandler(cmd)(context.Background(), eventJSON)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if args := result.(*CobraLambdaOutput).Args; args != nil {
+		t.Errorf("Expected no args without WithEchoArgs, got: %v", args)
+	}
+}
+
+func TestNewCobrLambdaHandler_EmptyPayloads(t *testing.T) {
+	for _, payload := range []string{"", "  ", "{}", "null"} {
+		t.Run(fmt.Sprintf("%q", payload), func(t *testing.T) {
+			var received []string
+			executed := false
+			cmd := &cobra.Command{
+				Use: "test",
+				Run: func(cmd *cobra.Command, args []string) {
+					executed = true
+					received = args
+					cmd.Println("root ran")
+				},
+			}
+
+			result, err := NewCobrLambdaHandler(cmd)(context.Background(), json.RawMessage(payload))
+
+			if err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if !executed {
+				t.Fatal("Command was not executed")
+			}
+			if len(received) != 0 {
+				t.Errorf("Expected no args, got: %v", received)
+			}
+
+			output := result.(*CobraLambdaOutput)
+			if !strings.Contains(output.Stdout, "root ran") {
+				t.Errorf("Expected output, got: %s", output.Stdout)
+			}
+		})
+	}
+}
+
+func TestEventFromArgs(t *testing.T) {
+	args := []string{"process", "--value", "two words"}
+	event := EventFromArgs(args)
+
+	if strings.Join(event.Args, "|") != "process|--value|two words" {
+		t.Fatalf("Expected args to be copied into the event, got: %v", event.Args)
+	}
+
+	args[0] = "changed"
+	if event.Args[0] != "process" {
+		t.Errorf("Expected event args to be independent of the input slice, got: %v", event.Args)
+	}
+
+	eventJSON, err := json.Marshal(EventFromArgs(nil))
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+	if string(eventJSON) != `{"args":[]}` {
+		t.Errorf("Expected nil args to encode as an empty list, got: %s", eventJSON)
+	}
+}
+
+func TestNewCobrLambdaHandler_NilCommand(t *testing.T) {
+	result, err := NewCobrLambdaHandler(nil)(context.Background(), json.RawMessage(`{"args": []}`))
+
+	if !errors.Is(err, ErrNilCommand) {
+		t.Fatalf("Expected ErrNilCommand, got: %v", err)
+	}
+	if result != nil {
+		t.Errorf("Expected nil result, got: %v", result)
+	}
+
+	if _, err := NewSNSHandler(nil)(context.Background(), events.SNSEvent{}); !errors.Is(err, ErrNilCommand) {
+		t.Errorf("Expected ErrNilCommand from SNS handler, got: %v", err)
+	}
+	if _, err := NewAPIGatewayHandler(nil)(context.Background(), events.APIGatewayProxyRequest{}); !errors.Is(err, ErrNilCommand) {
+		t.Errorf("Expected ErrNilCommand from API Gateway handler, got: %v", err)
+	}
+}
+
+func TestNewCobrLambdaHandler_DefaultCommand(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	for _, name := range []string{"status", "deploy"} {
+		root.AddCommand(&cobra.Command{
+			Use: name,
+			Run: func(cmd *cobra.Command, args []string) {
+				cmd.Printf("%s %v", cmd.Name(), args)
+			},
+		})
+	}
+
+	handler := NewCobrLambdaHandler(root, WithDefaultCommand([]string{"status", "short"}))
+
+	tests := []struct {
+		payload  string
+		expected string
+	}{
+		{payload: `{}`, expected: "status [short]"},
+		{payload: `{"args": []}`, expected: "status [short]"},
+		{payload: `{"args": ["deploy", "prod"]}`, expected: "deploy [prod]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.payload, func(t *testing.T) {
+			result, err := handler(context.Background(), json.RawMessage(tt.payload))
+			if err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+
+			if output := result.(*CobraLambdaOutput); output.Stdout != tt.expected {
+				t.Errorf("Expected %q, got: %q", tt.expected, output.Stdout)
+			}
+		})
+	}
+}
+
+func TestNewCobrLambdaHandler_EventContext(t *testing.T) {
+	var values map[string]string
+	root := &cobra.Command{
+		Use: "root",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			values = EventContext(cmd.Context())
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Print(EventContext(cmd.Context())["correlation_id"])
+		},
+	}
+
+	handler := NewCobrLambdaHandler(root)
+
+	eventJSON := json.RawMessage(`{"args": [], "context": {"correlation_id": "abc-123", "tenant": "acme"}}`)
+	result, err := handler(context.Background(), eventJSON)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if output := result.(*CobraLambdaOutput); output.Stdout != "abc-123" {
+		t.Errorf("Expected correlation id in output, got: %q", output.Stdout)
+	}
+	if values["tenant"] != "acme" {
+		t.Errorf("Expected values in PersistentPreRun, got: %v", values)
+	}
+
+	// values must not carry over to an invocation without context
+	if _, err := handler(context.Background(), json.RawMessage(`{"args": []}`)); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if values != nil {
+		t.Errorf("Expected no values without event context, got: %v", values)
+	}
+}
+
+func TestNewCobrLambdaHandler_ArgsWithSpaces(t *testing.T) {
+	var received []string
+	var title string
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			received = args
+		},
+	}
+	cmd.Flags().StringVar(&title, "title", "", "Title")
+
+	eventJSON, err := json.Marshal(EventFromArgs([]string{"hello world", "--title", "  padded  title ", "tab\there"}))
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+
+	if _, err := NewCobrLambdaHandler(cmd)(context.Background(), eventJSON); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if len(received) != 2 || received[0] != "hello world" || received[1] != "tab\there" {
+		t.Errorf("Expected args to survive intact, got: %q", received)
+	}
+	if title != "  padded  title " {
+		t.Errorf("Expected flag value to survive intact, got: %q", title)
+	}
+}
+
+func TestUnmarshalEvent_SyntaxErrorContext(t *testing.T) {
+	padding := strings.Repeat(`"x",`, 50)
+	payload := `{"args": [` + padding + `"greet" "bob"]}`
+	offset := strings.Index(payload, `"bob"`) + 1
+
+	_, err := UnmarshalEvent(json.RawMessage(payload))
+
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("Expected a wrapped *json.SyntaxError, got: %v", err)
+	}
+	if int(syntaxErr.Offset) != offset {
+		t.Fatalf("Expected offset %d, got: %d", offset, syntaxErr.Offset)
+	}
+
+	if !strings.Contains(err.Error(), fmt.Sprintf("at byte %d", offset)) {
+		t.Errorf("Expected the offset in the error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), `\"greet\" \"bob\"]}`) {
+		t.Errorf("Expected the surrounding input in the error, got: %v", err)
+	}
+	if strings.Contains(err.Error(), padding) {
+		t.Errorf("Expected only a snippet of the input, got: %v", err)
+	}
+}
+
+func TestUnmarshalEvent_TypeErrorContext(t *testing.T) {
+	_, err := UnmarshalEvent(json.RawMessage(`{"args": "greet bob"}`))
+
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("Expected a wrapped *json.UnmarshalTypeError, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "wrapper: invalid event at byte") {
+		t.Errorf("Got: %v", err)
+	}
+}
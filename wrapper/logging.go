@@ -0,0 +1,79 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+)
+
+// WithErrorLogger logs every failed execution to logger at error level with the
+// command path, args and Lambda request ID so failures can be diagnosed from CloudWatch
+func WithErrorLogger(logger *slog.Logger) Option {
+	return func(w *CobraLambda) {
+		w.errorLogger = logger
+	}
+}
+
+// logError logs err for an execution with args when an error logger is configured
+func (w *CobraLambda) logError(ctx context.Context, args []string, err error) {
+	if w.errorLogger == nil || err == nil {
+		return
+	}
+
+	path := w.cmd.CommandPath()
+	if target, _, findErr := w.cmd.Find(args); findErr == nil && target != nil {
+		path = target.CommandPath()
+	}
+
+	w.errorLogger.ErrorContext(ctx, "command failed",
+		slog.String("command", path),
+		slog.Any("args", args),
+		slog.String("request_id", RequestID(ctx)),
+		slog.String("error", err.Error()),
+	)
+}
+
+// WithStderrAsLog logs every line the command writes to stderr as an error level
+// entry on logger, tagged with stream=stderr and the Lambda request ID, so
+// CloudWatch Logs Insights can filter them. Captured output is unaffected
+func WithStderrAsLog(logger *slog.Logger) Option {
+	return func(w *CobraLambda) {
+		w.stderrLogger = logger
+	}
+}
+
+// stderrLogWriter returns the writer logging stderr lines for an execution with
+// ctx, or nil when WithStderrAsLog is not set
+func (w *CobraLambda) stderrLogWriter(ctx context.Context) *lineWriter {
+	if w.stderrLogger == nil {
+		return nil
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return &lineWriter{dst: &logWriter{ctx: ctx, logger: w.stderrLogger}}
+}
+
+// logWriter logs each line written to it at error level
+type logWriter struct {
+	ctx    context.Context
+	logger *slog.Logger
+}
+
+func (l *logWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimSuffix(p, []byte("\n")), []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(line) == 0 {
+			continue
+		}
+
+		l.logger.LogAttrs(l.ctx, slog.LevelError, string(line),
+			slog.String("stream", "stderr"),
+			slog.String("request_id", RequestID(l.ctx)),
+		)
+	}
+
+	return len(p), nil
+}
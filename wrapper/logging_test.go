@@ -0,0 +1,132 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/spf13/cobra"
+)
+
+func TestNewCobrLambdaHandler_ErrorLogger(t *testing.T) {
+	rootCmd := &cobra.Command{
+		Use: "root",
+	}
+	rootCmd.AddCommand(&cobra.Command{
+		Use:           "migrate",
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return errors.New("database unavailable")
+		},
+	})
+
+	logs := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, nil))
+
+	handler := NewCobrLambdaHandler(rootCmd, WithErrorLogger(logger))
+
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{
+		AwsRequestID: "req-456",
+	})
+
+	if _, err := handler(ctx, json.RawMessage(`{"args": ["migrate", "up"]}`)); err == nil {
+		t.Fatal("Expected error from command, got nil")
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a single JSON log record, got %q: %v", logs.String(), err)
+	}
+
+	expected := map[string]any{
+		"level":      "ERROR",
+		"msg":        "command failed",
+		"command":    "root migrate",
+		"request_id": "req-456",
+		"error":      "database unavailable",
+	}
+	for key, value := range expected {
+		if record[key] != value {
+			t.Errorf("Expected %s=%v, got: %v", key, value, record[key])
+		}
+	}
+
+	args, ok := record["args"].([]any)
+	if !ok || len(args) != 2 || args[0] != "migrate" || args[1] != "up" {
+		t.Errorf("Expected args [migrate up], got: %v", record["args"])
+	}
+}
+
+func TestNewCobrLambdaHandler_ErrorLoggerSuccess(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
+
+	logs := &bytes.Buffer{}
+	handler := NewCobrLambdaHandler(cmd, WithErrorLogger(slog.New(slog.NewJSONHandler(logs, nil))))
+
+	if _, err := handler(context.Background(), json.RawMessage(`{}`)); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("Expected no logs for a successful command, got: %s", logs.String())
+	}
+}
+
+func TestNewCobrLambdaHandler_StderrAsLog(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Fprintln(os.Stdout, "regular output")
+			fmt.Fprintln(os.Stderr, "warning: disk almost full")
+			fmt.Fprint(os.Stderr, "fatal: out of space")
+		},
+	}
+
+	logs := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, nil))
+
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{
+		AwsRequestID: "req-789",
+	})
+
+	output, err := NewTypedHandler(cmd, WithStderrAsLog(logger), WithoutMirror())(ctx, CobraLambdaEvent{})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if !strings.Contains(output.Stdout, "warning: disk almost full") {
+		t.Errorf("Expected stderr to still be captured, got: %s", output.Stdout)
+	}
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Failed to decode log record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 log records, got: %s", logs.String())
+	}
+
+	for i, msg := range []string{"warning: disk almost full", "fatal: out of space"} {
+		record := records[i]
+		if record["level"] != "ERROR" || record["msg"] != msg {
+			t.Errorf("Expected error level record %q, got: %v", msg, record)
+		}
+		if record["stream"] != "stderr" || record["request_id"] != "req-789" {
+			t.Errorf("Expected stream and request_id attributes, got: %v", record)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package wrapper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	// ResponseFormatJSON is the default response format, the output itself
+	ResponseFormatJSON = "json"
+	// ResponseFormatMsgpack returns the output MessagePack encoded inside an
+	// EncodedResponse. The payload is base64 encoded as a whole, so it is about a
+	// third larger than plain text output sent as JSON and only smaller for
+	// output JSON escapes heavily, such as HTML or terminal escape sequences
+	ResponseFormatMsgpack = "msgpack"
+)
+
+// ErrUnsupportedResponseFormat is returned for events asking for a response
+// format other than ResponseFormatJSON or ResponseFormatMsgpack
+var ErrUnsupportedResponseFormat = errors.New("wrapper: unsupported response format")
+
+// EncodedResponse is returned by NewCobrLambdaHandler in place of the output
+// when the event asks for a format other than JSON. Lambda responses are
+// always JSON, Payload holds the encoded output and is base64 encoded by it.
+// Use DecodeResponse to read either kind of response
+type EncodedResponse struct {
+	Format  string `json:"format"`
+	Payload []byte `json:"payload"`
+}
+
+func checkResponseFormat(format string) error {
+	switch format {
+	case "", ResponseFormatJSON, ResponseFormatMsgpack:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedResponseFormat, format)
+	}
+}
+
+// EncodeResponse encodes output in format, only ResponseFormatMsgpack is
+// supported. Field names are the same as in the JSON output. Base64 encoded
+// output is encoded as the raw bytes since the payload is base64 encoded again
+func EncodeResponse(output *CobraLambdaOutput, format string) (*EncodedResponse, error) {
+	if format != ResponseFormatMsgpack {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedResponseFormat, format)
+	}
+
+	if output.Encoding == EncodingBase64 || output.StderrEncoding == EncodingBase64 {
+		decoded := *output
+		if err := decodeBase64(&decoded.Stdout, decoded.Encoding); err != nil {
+			return nil, fmt.Errorf("wrapper: encoding msgpack response: %w", err)
+		}
+		if err := decodeBase64(&decoded.Stderr, decoded.StderrEncoding); err != nil {
+			return nil, fmt.Errorf("wrapper: encoding msgpack response: %w", err)
+		}
+		output = &decoded
+	}
+
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.SetOmitEmpty(true)
+
+	if err := enc.Encode(output); err != nil {
+		return nil, fmt.Errorf("wrapper: encoding msgpack response: %w", err)
+	}
+
+	return &EncodedResponse{Format: format, Payload: buf.Bytes()}, nil
+}
+
+// DecodeResponse decodes a handler response, either the output as JSON or an
+// EncodedResponse holding it
+func DecodeResponse(payload []byte) (*CobraLambdaOutput, error) {
+	var encoded EncodedResponse
+	if err := json.Unmarshal(payload, &encoded); err != nil {
+		return nil, err
+	}
+
+	output := &CobraLambdaOutput{}
+
+	switch encoded.Format {
+	case "":
+		if err := json.Unmarshal(payload, output); err != nil {
+			return nil, err
+		}
+	case ResponseFormatMsgpack:
+		dec := msgpack.NewDecoder(bytes.NewReader(encoded.Payload))
+		dec.SetCustomStructTag("json")
+
+		if err := dec.Decode(output); err != nil {
+			return nil, fmt.Errorf("wrapper: decoding msgpack response: %w", err)
+		}
+
+		// the output is returned as it would be in the JSON response
+		if output.Encoding == EncodingBase64 {
+			output.Stdout = base64.StdEncoding.EncodeToString([]byte(output.Stdout))
+		}
+		if output.StderrEncoding == EncodingBase64 {
+			output.Stderr = base64.StdEncoding.EncodeToString([]byte(output.Stderr))
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedResponseFormat, encoded.Format)
+	}
+
+	return output, nil
+}
+
+// decodeBase64 replaces s with the bytes it holds when encoding is
+// EncodingBase64
+func decodeBase64(s *string, encoding string) error {
+	if encoding != EncodingBase64 {
+		return nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(*s)
+	if err != nil {
+		return err
+	}
+	*s = string(raw)
+	return nil
+}
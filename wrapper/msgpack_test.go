@@ -0,0 +1,136 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestEncodeResponse_RoundTrip(t *testing.T) {
+	output := &CobraLambdaOutput{
+		Stdout:     strings.Repeat("\x01\x02\"\\<>", 100),
+		ExitCode:   3,
+		Args:       []string{"dump", "--raw"},
+		DurationMs: 12,
+	}
+
+	encoded, err := EncodeResponse(output, ResponseFormatMsgpack)
+	if err != nil {
+		t.Fatalf("EncodeResponse failed: %v", err)
+	}
+
+	payload, err := json.Marshal(encoded)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+
+	plain, _ := json.Marshal(output)
+	if len(payload) >= len(plain) {
+		t.Errorf("Expected msgpack smaller than JSON for escaped output, got %d >= %d", len(payload), len(plain))
+	}
+
+	decoded, err := DecodeResponse(payload)
+	if err != nil {
+		t.Fatalf("DecodeResponse failed: %v", err)
+	}
+
+	if decoded.Stdout != output.Stdout || decoded.ExitCode != 3 || decoded.DurationMs != 12 || fmt.Sprint(decoded.Args) != "[dump --raw]" {
+		t.Errorf("Expected %+v, got %+v", output, decoded)
+	}
+}
+
+func TestEncodeResponse_Base64Output(t *testing.T) {
+	output := &CobraLambdaOutput{
+		Stdout:         base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0xff, 0xfe, 0x00}, 1000)),
+		Encoding:       EncodingBase64,
+		Stderr:         base64.StdEncoding.EncodeToString([]byte("err\xff")),
+		StderrEncoding: EncodingBase64,
+	}
+
+	encoded, err := EncodeResponse(output, ResponseFormatMsgpack)
+	if err != nil {
+		t.Fatalf("EncodeResponse failed: %v", err)
+	}
+
+	payload, err := json.Marshal(encoded)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+
+	// the raw bytes are encoded so the output is not base64 encoded twice
+	plain, _ := json.Marshal(output)
+	if len(payload) > len(plain) {
+		t.Errorf("Expected msgpack no larger than JSON for base64 output, got %d > %d", len(payload), len(plain))
+	}
+
+	decoded, err := DecodeResponse(payload)
+	if err != nil {
+		t.Fatalf("DecodeResponse failed: %v", err)
+	}
+	if decoded.Stdout != output.Stdout || decoded.Encoding != EncodingBase64 || decoded.Stderr != output.Stderr {
+		t.Errorf("Expected the base64 output back, got %+v", decoded)
+	}
+}
+
+func TestDecodeResponse_JSON(t *testing.T) {
+	decoded, err := DecodeResponse([]byte(`{"stdout":"hello\n","error":"","exitCode":0}`))
+	if err != nil {
+		t.Fatalf("DecodeResponse failed: %v", err)
+	}
+
+	if decoded.Stdout != "hello\n" {
+		t.Errorf("Got: %s", decoded.Stdout)
+	}
+}
+
+func TestNewCobrLambdaHandler_ResponseFormat(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Print("packed")
+		},
+	}
+
+	handler := NewCobrLambdaHandler(cmd, WithoutMirror())
+
+	result, err := handler(context.TODO(), json.RawMessage(`{"args":[],"responseFormat":"msgpack"}`))
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	response, ok := result.(*EncodedResponse)
+	if !ok {
+		t.Fatalf("Expected *EncodedResponse, got %T", result)
+	}
+
+	// as the Lambda runtime would
+	payload, _ := json.Marshal(response)
+
+	output, err := DecodeResponse(payload)
+	if err != nil {
+		t.Fatalf("DecodeResponse failed: %v", err)
+	}
+	if output.Stdout != "packed" {
+		t.Errorf("Got: %s", output.Stdout)
+	}
+
+	result, err = handler(context.TODO(), json.RawMessage(`{"args":[],"responseFormat":"json"}`))
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if _, ok := result.(*CobraLambdaOutput); !ok {
+		t.Errorf("Expected *CobraLambdaOutput for json, got %T", result)
+	}
+
+	_, err = handler(context.TODO(), json.RawMessage(`{"args":[],"responseFormat":"xml"}`))
+	if !errors.Is(err, ErrUnsupportedResponseFormat) {
+		t.Errorf("Expected ErrUnsupportedResponseFormat, got %v", err)
+	}
+}
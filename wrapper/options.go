@@ -0,0 +1,76 @@
+package wrapper
+
+import (
+	"strings"
+	"time"
+)
+
+// defaultDeadlineMargin is how long before the context deadline Execute stops
+// waiting on the command and returns whatever output has been captured
+const defaultDeadlineMargin = 500 * time.Millisecond
+
+// Option configures a CobraLambda
+type Option func(*CobraLambda)
+
+// WithDeadlineMargin sets how long before the context deadline the command is
+// abandoned so partial output can still be returned before Lambda times out
+func WithDeadlineMargin(d time.Duration) Option {
+	return func(w *CobraLambda) {
+		w.deadlineMargin = d
+	}
+}
+
+// WithTrimTrailingNewline trims a single trailing newline from captured output
+func WithTrimTrailingNewline() Option {
+	return func(w *CobraLambda) {
+		w.trimTrailingNewline = true
+	}
+}
+
+// WithEchoArgs records the arguments the command received in CobraLambdaOutput.Args
+func WithEchoArgs() Option {
+	return func(w *CobraLambda) {
+		w.echoArgs = true
+	}
+}
+
+// WithoutMirror stops captured output from also being written to the original
+// stdout and stderr, which on Lambda keeps it out of CloudWatch logs. It is
+// shorthand for WithMirror(false, false)
+func WithoutMirror() Option {
+	return WithMirror(false, false)
+}
+
+// WithMirror chooses separately whether captured stdout and stderr are also
+// written to the original stdout and stderr, e.g. WithMirror(false, true) keeps
+// only errors in CloudWatch logs. Both are mirrored by default
+func WithMirror(stdout, stderr bool) Option {
+	return func(w *CobraLambda) {
+		w.mirrorStdout = nil
+		if stdout {
+			w.mirrorStdout = w.originalStdout
+		}
+
+		w.mirrorStderr = nil
+		if stderr {
+			w.mirrorStderr = w.originalStderr
+		}
+	}
+}
+
+// WithDefaultCommand runs args, e.g. []string{"status"}, when an invocation has
+// no arguments instead of the root command. Invocations with any arguments,
+// including only flags, are not affected
+func WithDefaultCommand(args []string) Option {
+	return func(w *CobraLambda) {
+		w.defaultArgs = append([]string{}, args...)
+	}
+}
+
+// trimTrailingNewline removes one trailing "\n" or "\r\n" from s
+func trimTrailingNewline(s string) string {
+	if strings.HasSuffix(s, "\r\n") {
+		return s[:len(s)-2]
+	}
+	return strings.TrimSuffix(s, "\n")
+}
@@ -0,0 +1,51 @@
+package wrapper
+
+import (
+	"os"
+	"sync"
+)
+
+// osArgsMu serializes executions overriding os.Args across wrappers
+var osArgsMu sync.Mutex
+
+// WithOsArgsOverride sets os.Args to the program name followed by the event
+// args while the command runs, for legacy commands that read os.Args instead of
+// the args cobra passes them. The program name is the one set by WithProgramName
+// or the root command's name. os.Args is restored once execution returns
+func WithOsArgsOverride() Option {
+	return func(w *CobraLambda) {
+		w.osArgsOverride = true
+	}
+}
+
+// overrideOsArgs points os.Args at args for the duration of an execution, or
+// only replaces os.Args[0] with the program name when just WithProgramName is
+// set, returning the function that restores it
+func (w *CobraLambda) overrideOsArgs(args []string) func() {
+	if !w.osArgsOverride && w.programName == "" {
+		return func() {}
+	}
+
+	name := w.programName
+	if name == "" {
+		name = w.cmd.Root().Name()
+	}
+
+	osArgsMu.Lock()
+	saved := os.Args
+
+	if w.osArgsOverride {
+		os.Args = append([]string{name}, args...)
+	} else {
+		rest := []string{}
+		if len(saved) > 1 {
+			rest = saved[1:]
+		}
+		os.Args = append([]string{name}, rest...)
+	}
+
+	return func() {
+		os.Args = saved
+		osArgsMu.Unlock()
+	}
+}
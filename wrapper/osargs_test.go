@@ -0,0 +1,100 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_OsArgsOverride(t *testing.T) {
+	original := append([]string{}, os.Args...)
+
+	cmd := &cobra.Command{
+		Use:                "legacy",
+		DisableFlagParsing: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("os.Args=%s\n", strings.Join(os.Args, "|"))
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithOsArgsOverride(), WithoutMirror())
+	output, err := wrapper.Execute([]string{"--name", "two words"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if !strings.Contains(output.Stdout, "os.Args=legacy|--name|two words") {
+		t.Errorf("Expected os.Args to hold the event args, got: %s", output.Stdout)
+	}
+	if strings.Join(os.Args, "|") != strings.Join(original, "|") {
+		t.Errorf("Expected os.Args to be restored, got: %v", os.Args)
+	}
+}
+
+func TestCobraWrapper_OsArgsOverrideProgramName(t *testing.T) {
+	cmd := &cobra.Command{
+		Use:                "legacy",
+		DisableFlagParsing: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("os.Args=%s\n", strings.Join(os.Args, "|"))
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithOsArgsOverride(), WithProgramName("tool"), WithoutMirror())
+	output, err := wrapper.Execute([]string{"run"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if !strings.Contains(output.Stdout, "os.Args=tool|run") {
+		t.Errorf("Expected program name in os.Args, got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_WithoutOsArgsOverride(t *testing.T) {
+	cmd := &cobra.Command{
+		Use:                "legacy",
+		DisableFlagParsing: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("os.Args=%s\n", strings.Join(os.Args, "|"))
+		},
+	}
+
+	output, err := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror()).Execute([]string{"run"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if !strings.Contains(output.Stdout, "os.Args="+strings.Join(os.Args, "|")) {
+		t.Errorf("Expected os.Args to be untouched, got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_ProgramNameSetsOsArgs0(t *testing.T) {
+	original := append([]string{}, os.Args...)
+
+	cmd := &cobra.Command{
+		Use: "legacy",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("name=%s args=%d", filepath.Base(os.Args[0]), len(os.Args))
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithProgramName("/usr/local/bin/tool"), WithoutMirror())
+	output, err := wrapper.Execute([]string{"run"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if output.Stdout != fmt.Sprintf("name=tool args=%d", len(original)) {
+		t.Errorf("Expected os.Args[0] to be the program name with the rest untouched, got: %s", output.Stdout)
+	}
+	if strings.Join(os.Args, "|") != strings.Join(original, "|") {
+		t.Errorf("Expected os.Args to be restored, got: %v", os.Args)
+	}
+}
@@ -0,0 +1,185 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// redactedMask replaces text matched by redaction patterns
+const redactedMask = "****"
+
+// WithRedactor masks every match of patterns in captured output with "****"
+// before it is returned. Output mirrored to the original stdout/stderr is not
+// redacted, combine with WithoutMirror to keep secrets out of logs entirely
+func WithRedactor(patterns []*regexp.Regexp) Option {
+	return func(w *CobraLambda) {
+		w.redactPatterns = append(w.redactPatterns, patterns...)
+	}
+}
+
+// WithCollapseCarriageReturns keeps only the final state of lines rewritten
+// with "\r", such as progress bars, as a terminal would display them. Text after
+// a "\r" overwrites the start of the line, a "\r\n" line ending is kept. By
+// default output is returned verbatim
+func WithCollapseCarriageReturns() Option {
+	return func(w *CobraLambda) {
+		w.collapseCarriageReturns = true
+	}
+}
+
+// WithStripANSI removes ANSI escape sequences, such as colors and cursor
+// movement, from captured output before it is returned, for clients writing it
+// to plain logs. Output streamed with ExecuteStream or WithSink is not stripped
+func WithStripANSI() Option {
+	return func(w *CobraLambda) {
+		w.stripANSI = true
+	}
+}
+
+// transformOutput applies the configured post-processing to captured output
+func (w *CobraLambda) transformOutput(stdout string) string {
+	// escape sequences would otherwise count as columns when collapsing lines
+	// and could split text matched by redaction patterns
+	if w.stripANSI {
+		stdout = stripANSI(stdout)
+	}
+
+	if w.collapseCarriageReturns {
+		stdout = collapseCarriageReturns(stdout)
+	}
+
+	for _, pattern := range w.redactPatterns {
+		stdout = pattern.ReplaceAllLiteralString(stdout, redactedMask)
+	}
+
+	if w.trimTrailingNewline {
+		stdout = trimTrailingNewline(stdout)
+	}
+
+	return stdout
+}
+
+// JSONLines splits Stdout into lines and returns each as raw JSON, for commands
+// emitting one JSON document per line. Blank lines are skipped and the first line
+// that is not valid JSON produces an error naming its 1-based line number
+func (o *CobraLambdaOutput) JSONLines() ([]json.RawMessage, error) {
+	lines := []json.RawMessage{}
+
+	for i, line := range strings.Split(o.Stdout, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var raw json.RawMessage
+		if err := json.Unmarshal([]byte(line), &raw); err != nil {
+			return nil, fmt.Errorf("wrapper: line %d is not valid JSON: %w", i+1, err)
+		}
+
+		lines = append(lines, raw)
+	}
+
+	return lines, nil
+}
+
+// collapseCarriageReturns overlays the "\r" separated segments of each line of
+// s, leaving what a terminal would show
+func collapseCarriageReturns(s string) string {
+	if !strings.Contains(s, "\r") {
+		return s
+	}
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		ending := ""
+		if strings.HasSuffix(line, "\r") && i < len(lines)-1 {
+			line, ending = line[:len(line)-1], "\r"
+		}
+
+		segments := strings.Split(line, "\r")
+		screen := []rune(segments[0])
+		for _, segment := range segments[1:] {
+			overwrite := []rune(segment)
+			if len(overwrite) >= len(screen) {
+				screen = overwrite
+			} else {
+				copy(screen, overwrite)
+			}
+		}
+
+		lines[i] = string(screen) + ending
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// StripANSI returns Stdout with ANSI escape sequences removed, see
+// WithStripANSI. Stdout is returned as is when it is base64 encoded
+func (o *CobraLambdaOutput) StripANSI() string {
+	if o.Encoding != "" {
+		return o.Stdout
+	}
+	return stripANSI(o.Stdout)
+}
+
+// stripANSI removes CSI sequences such as "\x1b[1;31m", OSC sequences such as
+// hyperlinks and two byte escape sequences from s. A sequence cut off at the
+// end of s, e.g. by a command abandoned mid-write, is removed as well
+func stripANSI(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); {
+		if s[i] != 0x1b {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		i = skipEscape(s, i)
+	}
+
+	return b.String()
+}
+
+// skipEscape returns the index just past the escape sequence starting at s[i]
+func skipEscape(s string, i int) int {
+	i++ // ESC
+	if i >= len(s) {
+		return i
+	}
+
+	switch s[i] {
+	case '[':
+		// parameter and intermediate bytes up to a final byte in 0x40-0x7E
+		for i++; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+			if s[i] < 0x20 || s[i] > 0x3f {
+				// not a valid CSI sequence, keep what follows
+				return i
+			}
+		}
+		return i
+	case ']':
+		// terminated by BEL or ST ("\x1b\\")
+		for i++; i < len(s); i++ {
+			if s[i] == 0x07 {
+				return i + 1
+			}
+			if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return i
+	default:
+		return i + 1
+	}
+}
@@ -0,0 +1,205 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_WithRedactor(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Println("token=ghp_abcdef1234567890")
+			fmt.Println("password: hunter2")
+			cmd.Println("nothing secret here")
+		},
+	}
+
+	patterns := []*regexp.Regexp{
+		regexp.MustCompile(`ghp_[A-Za-z0-9]+`),
+		regexp.MustCompile(`hunter\d`),
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithRedactor(patterns))
+	output, err := wrapper.Execute([]string{})
+
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if strings.Contains(output.Stdout, "ghp_") || strings.Contains(output.Stdout, "hunter2") {
+		t.Errorf("Expected secrets to be redacted. Got: %s", output.Stdout)
+	}
+	if !strings.Contains(output.Stdout, "token=****") || !strings.Contains(output.Stdout, "password: ****") {
+		t.Errorf("Expected masked values. Got: %s", output.Stdout)
+	}
+	if !strings.Contains(output.Stdout, "nothing secret here") {
+		t.Errorf("Expected other output untouched. Got: %s", output.Stdout)
+	}
+}
+
+func TestCobraLambdaOutput_JSONLines(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println(`{"id": 1, "name": "first"}`)
+			fmt.Println()
+			fmt.Println(`{"id": 2, "name": "second"}`)
+			fmt.Println(`[3]`)
+		},
+	}
+
+	output, err := NewCobraLambdaCLI(context.TODO(), cmd).Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	lines, err := output.JSONLines()
+	if err != nil {
+		t.Fatalf("JSONLines failed: %v", err)
+	}
+
+	expected := []string{`{"id": 1, "name": "first"}`, `{"id": 2, "name": "second"}`, `[3]`}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got: %q", len(expected), lines)
+	}
+	for i, line := range lines {
+		if string(line) != expected[i] {
+			t.Errorf("Expected line %d to be %s, got: %s", i, expected[i], line)
+		}
+	}
+}
+
+func TestCobraLambdaOutput_JSONLinesInvalid(t *testing.T) {
+	output := &CobraLambdaOutput{
+		Stdout: "{\"ok\": true}\nprocessing 2 records\n{\"ok\": false}\n",
+	}
+
+	lines, err := output.JSONLines()
+	if err == nil {
+		t.Fatalf("Expected error for mixed output, got lines: %q", lines)
+	}
+	if !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected error to name line 2, got: %v", err)
+	}
+
+	output = &CobraLambdaOutput{}
+	lines, err = output.JSONLines()
+	if err != nil || len(lines) != 0 {
+		t.Errorf("Expected no lines and no error for empty output, got: %q, %v", lines, err)
+	}
+}
+
+func TestCobraWrapper_WithCollapseCarriageReturns(t *testing.T) {
+	progress := "downloading\n\r[    ] 0%\r[==  ] 50%\r[====] 100%\ndone\n"
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Print(progress)
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithCollapseCarriageReturns())
+
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if output.Stdout != "downloading\n[====] 100%\ndone\n" {
+		t.Errorf("Got: %q", output.Stdout)
+	}
+
+	verbatim, err := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror()).Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if verbatim.Stdout != progress {
+		t.Errorf("Expected output verbatim by default, got: %q", verbatim.Stdout)
+	}
+}
+
+func TestCollapseCarriageReturns(t *testing.T) {
+	tests := map[string]string{
+		"plain\n":                      "plain\n",
+		"10%\r100%":                    "100%",
+		"loading...\rok":               "okading...",
+		"windows\r\nline\r\n":          "windows\r\nline\r\n",
+		"a\rb\r\nc":                    "b\r\nc",
+		"héllo wörld\rbye":             "byelo wörld",
+		"step 1/3\rstep 2/3\rstep 3/3": "step 3/3",
+	}
+
+	for input, want := range tests {
+		if got := collapseCarriageReturns(input); got != want {
+			t.Errorf("collapseCarriageReturns(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "no escapes\n", want: "no escapes\n"},
+		{name: "sgr", in: "\x1b[1;31merror\x1b[0m: failed\n", want: "error: failed\n"},
+		{name: "reset shorthand", in: "\x1b[32mok\x1b[m", want: "ok"},
+		{name: "256 colors", in: "\x1b[38;5;208morange\x1b[39m", want: "orange"},
+		{name: "cursor movement", in: "50%\x1b[2K\x1b[1G100%", want: "50%100%"},
+		{name: "hyperlink", in: "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x07 text", want: "link text"},
+		{name: "two byte", in: "\x1b7saved\x1b8", want: "saved"},
+		{name: "unicode", in: "\x1b[34m✓ done\x1b[0m", want: "✓ done"},
+		{name: "cut off at end", in: "partial\x1b[1;3", want: "partial"},
+		{name: "lone escape at end", in: "partial\x1b", want: "partial"},
+		{name: "unterminated osc", in: "text\x1b]0;title", want: "text"},
+		{name: "invalid csi keeps text", in: "a\x1b[1\nb", want: "a\nb"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripANSI(tt.in); got != tt.want {
+				t.Errorf("stripANSI(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCobraWrapper_WithStripANSI(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Print("\x1b[1;32mPASS\x1b[0m token=\x1b[33msecret\x1b[0m\n")
+			fmt.Print("\x1b[33mloading\x1b[0m\rdone   \n")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithStripANSI(), WithCollapseCarriageReturns(),
+		WithRedactor([]*regexp.Regexp{regexp.MustCompile(`token=\S+`)}))
+
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if output.Stdout != "PASS ****\ndone   \n" {
+		t.Errorf("Got: %q", output.Stdout)
+	}
+}
+
+func TestCobraLambdaOutput_StripANSI(t *testing.T) {
+	output := &CobraLambdaOutput{Stdout: "\x1b[31mred\x1b[0m"}
+	if got := output.StripANSI(); got != "red" {
+		t.Errorf("Got: %q", got)
+	}
+
+	encoded := &CobraLambdaOutput{Stdout: "G1szMW0=", Encoding: "base64"}
+	if got := encoded.StripANSI(); got != "G1szMW0=" {
+		t.Errorf("Expected base64 output untouched, got: %q", got)
+	}
+}
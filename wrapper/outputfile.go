@@ -0,0 +1,75 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// WithOutputFile writes captured output to the file at path instead of keeping
+// it in memory, for commands producing more output than should be held in a
+// response. The file is truncated on every execution and CobraLambdaOutput
+// carries its path and size in place of Stdout. Output post-processing such as
+// WithRedactor and WithTrimTrailingNewline is not applied to the file. Call
+// CobraLambdaOutput.Close to remove the file once it has been consumed
+func WithOutputFile(path string) Option {
+	return func(w *CobraLambda) {
+		w.outputFile = path
+	}
+}
+
+// createOutputFile opens the configured output file, nil when output is kept in memory
+func (w *CobraLambda) createOutputFile() (*os.File, error) {
+	if w.outputFile == "" {
+		return nil, nil
+	}
+
+	f, err := os.Create(w.outputFile)
+	if err != nil {
+		return nil, fmt.Errorf("wrapper: creating output file: %w", err)
+	}
+
+	return f, nil
+}
+
+// discardOutputFile closes and removes an output file that was never written
+func (w *CobraLambda) discardOutputFile(f *os.File) {
+	if f == nil {
+		return
+	}
+
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+}
+
+// finishOutputFile closes f and records its path and size on output
+func finishOutputFile(output *CobraLambdaOutput, f *os.File) error {
+	info, statErr := f.Stat()
+	closeErr := f.Close()
+
+	output.OutputFile = f.Name()
+	if statErr == nil {
+		output.OutputSize = info.Size()
+	}
+
+	if err := errors.Join(statErr, closeErr); err != nil {
+		return fmt.Errorf("wrapper: finishing output file: %w", err)
+	}
+
+	return nil
+}
+
+// Close removes OutputFile, if any. It is safe to call on output kept in memory
+// and more than once
+func (o *CobraLambdaOutput) Close() error {
+	if o.OutputFile == "" {
+		return nil
+	}
+
+	if err := os.Remove(o.OutputFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_OutputFile(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			for i := 0; i < 1000; i++ {
+				fmt.Printf("line %d\n", i)
+			}
+		},
+	}
+
+	var expected strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&expected, "line %d\n", i)
+	}
+
+	path := filepath.Join(t.TempDir(), "output.txt")
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithOutputFile(path))
+
+	// run twice, the file must be truncated between executions
+	for run := 0; run < 2; run++ {
+		output, err := wrapper.Execute([]string{})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+
+		if output.Stdout != "" {
+			t.Errorf("Expected empty Stdout, got %d bytes", len(output.Stdout))
+		}
+		if output.OutputFile != path {
+			t.Errorf("Expected OutputFile %q, got: %q", path, output.OutputFile)
+		}
+		if output.OutputSize != int64(expected.Len()) {
+			t.Errorf("Expected OutputSize %d, got: %d", expected.Len(), output.OutputSize)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read output file: %v", err)
+		}
+		if string(data) != expected.String() {
+			t.Errorf("Output file does not match expected output. Got %d bytes", len(data))
+		}
+	}
+}
+
+func TestCobraWrapper_OutputFileClose(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Println("hello")
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "output.txt")
+	output, err := NewCobraLambdaCLI(context.TODO(), cmd, WithOutputFile(path)).Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if err := output.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected output file to be removed, got: %v", err)
+	}
+
+	// closing again, or closing in-memory output, is a no-op
+	if err := output.Close(); err != nil {
+		t.Errorf("Expected second Close to succeed, got: %v", err)
+	}
+	if err := (&CobraLambdaOutput{Stdout: "hello"}).Close(); err != nil {
+		t.Errorf("Expected Close on in-memory output to succeed, got: %v", err)
+	}
+}
+
+func TestCobraWrapper_OutputFileCreateError(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
+
+	path := filepath.Join(t.TempDir(), "missing", "output.txt")
+	_, err := NewCobraLambdaCLI(context.TODO(), cmd, WithOutputFile(path)).Execute([]string{})
+	if err == nil || !strings.Contains(err.Error(), "wrapper: creating output file") {
+		t.Errorf("Expected output file creation error, got: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// DefaultOutputSizeWarning is the output size in bytes past which handlers warn,
+// leaving headroom below Lambda's 6MB limit on synchronous responses
+const DefaultOutputSizeWarning = 5 * 1024 * 1024
+
+// WithOutputSizeWarning sets the output size in bytes past which handlers log a
+// warning, DefaultOutputSizeWarning by default. Output is not truncated. The
+// warning goes to the WithErrorLogger logger when set and to the original
+// stderr otherwise. n <= 0 disables the warning
+func WithOutputSizeWarning(n int) Option {
+	return func(w *CobraLambda) {
+		w.outputSizeWarning = n
+	}
+}
+
+// outputBytes is the size of the captured output returned inline
+func outputBytes(output *CobraLambdaOutput) int {
+	return len(output.Stdout) + len(output.Stderr)
+}
+
+// warnOutputSize warns when output is larger than the configured threshold
+func (w *CobraLambda) warnOutputSize(ctx context.Context, args []string, output *CobraLambdaOutput) {
+	if output == nil || w.outputSizeWarning <= 0 || output.OutputBytes <= w.outputSizeWarning {
+		return
+	}
+
+	if w.errorLogger == nil {
+		_, _ = fmt.Fprintf(w.originalStderr, "wrapper: output of %d bytes exceeds the %d byte warning threshold, Lambda rejects synchronous responses over 6MB\n",
+			output.OutputBytes, w.outputSizeWarning)
+		return
+	}
+
+	w.errorLogger.WarnContext(ctx, "output size exceeds warning threshold",
+		slog.Any("args", args),
+		slog.String("request_id", RequestID(ctx)),
+		slog.Int("output_bytes", output.OutputBytes),
+		slog.Int("threshold", w.outputSizeWarning),
+	)
+}
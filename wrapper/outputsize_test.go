@@ -0,0 +1,74 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_OutputBytes(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Print(strings.Repeat("x", 1234))
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror())
+
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if output.OutputBytes != 1234 {
+		t.Errorf("Expected 1234 output bytes, got %d", output.OutputBytes)
+	}
+}
+
+func TestNewCobrLambdaHandler_OutputSizeWarning(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int
+		warns bool
+	}{
+		{name: "below threshold", size: 100},
+		{name: "at threshold", size: 128},
+		{name: "above threshold", size: 129, warns: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := &bytes.Buffer{}
+			logger := slog.New(slog.NewJSONHandler(logs, nil))
+
+			cmd := &cobra.Command{
+				Use: "test",
+				Run: func(cmd *cobra.Command, args []string) {
+					fmt.Print(strings.Repeat("x", tt.size))
+				},
+			}
+
+			handler := NewCobrLambdaHandler(cmd, WithoutMirror(), WithErrorLogger(logger), WithOutputSizeWarning(128))
+
+			if _, err := handler(context.TODO(), json.RawMessage(`{"args":[]}`)); err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+
+			warned := strings.Contains(logs.String(), "output size exceeds warning threshold")
+			if warned != tt.warns {
+				t.Errorf("Expected warning %v, got logs: %s", tt.warns, logs.String())
+			}
+
+			if tt.warns && !strings.Contains(logs.String(), fmt.Sprintf(`"output_bytes":%d`, tt.size)) {
+				t.Errorf("Expected the output size in the warning, got: %s", logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// NewRawHandler returns a handler whose response is the command's output itself
+// rather than CobraLambdaOutput, for integrations expecting stdout to be the
+// response. Output that is valid JSON is returned as that JSON, anything else
+// as a JSON string, e.g. {"id": 1} and "done" for output "done\n" with
+// WithTrimTrailingNewline. Events are decoded like NewCobrLambdaHandler does.
+// A failing command returns its error and no response
+func NewRawHandler(cmd *cobra.Command, opts ...Option) CobraLambdaFunc {
+	return func(ctx context.Context, eventJSON json.RawMessage) (any, error) {
+		if cmd == nil {
+			return nil, ErrNilCommand
+		}
+
+		event, err := UnmarshalEvent(eventJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		lambda := newCobraLambda(ctx, cmd, opts...)
+
+		output, err := lambda.handle(withRawEvent(ctx, eventJSON), event)
+		if err != nil {
+			return nil, err
+		}
+
+		return rawResponse(output), nil
+	}
+}
+
+// rawResponse returns Stdout as raw JSON when it is valid JSON and as a string
+// otherwise, including when it is base64 encoded
+func rawResponse(output *CobraLambdaOutput) any {
+	trimmed := strings.TrimSpace(output.Stdout)
+	if output.Encoding == "" && trimmed != "" && json.Valid([]byte(trimmed)) {
+		return json.RawMessage(trimmed)
+	}
+
+	return output.Stdout
+}
@@ -0,0 +1,77 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestNewRawHandler(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	root.AddCommand(&cobra.Command{
+		Use: "text",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Println("plain text")
+		},
+	})
+	root.AddCommand(&cobra.Command{
+		Use: "json",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Println(`{"id": 1, "tags": ["a"]}`)
+		},
+	})
+	root.AddCommand(&cobra.Command{
+		Use: "number",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Print("42")
+		},
+	})
+
+	handler := NewRawHandler(root, WithoutMirror())
+
+	for _, tt := range []struct {
+		args string
+		want string
+	}{
+		{args: `["text"]`, want: `"plain text\n"`},
+		{args: `["json"]`, want: `{"id":1,"tags":["a"]}`},
+		{args: `["number"]`, want: `42`},
+	} {
+		response, err := handler(context.Background(), json.RawMessage(`{"args": `+tt.args+`}`))
+		if err != nil {
+			t.Fatalf("Handler failed for %s: %v", tt.args, err)
+		}
+
+		// the Lambda runtime marshals the response as JSON
+		data, err := json.Marshal(response)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("Expected response %s for %s, got %s", tt.want, tt.args, data)
+		}
+	}
+}
+
+func TestNewRawHandler_Error(t *testing.T) {
+	cmd := &cobra.Command{
+		Use:           "test",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.Print("partial")
+			return errFailed
+		},
+	}
+
+	response, err := NewRawHandler(cmd, WithoutMirror())(context.Background(), json.RawMessage(`{"args": []}`))
+	if !errors.Is(err, errFailed) {
+		t.Errorf("Expected errFailed, got %v", err)
+	}
+	if response != nil {
+		t.Errorf("Expected no response on error, got %v", response)
+	}
+}
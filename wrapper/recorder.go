@@ -0,0 +1,115 @@
+package wrapper
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// RecordedInvocation is a line written by WithRecorder and read by Replay
+type RecordedInvocation struct {
+	RequestID string           `json:"requestId,omitempty"`
+	Time      time.Time        `json:"time"`
+	Event     CobraLambdaEvent `json:"event"`
+}
+
+// recorder serializes writes of recorded invocations from concurrent handlers
+type recorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// WithRecorder appends every incoming event to w as a line of JSON holding the
+// request ID, the time it was received and the event, before the command runs.
+// The recording can be fed to Replay to reproduce invocations locally. Failing
+// to record is reported on stderr and does not fail the invocation
+func WithRecorder(w io.Writer) Option {
+	rec := &recorder{w: w}
+
+	return func(l *CobraLambda) {
+		l.recorder = rec
+	}
+}
+
+func (r *recorder) record(ctx context.Context, event *CobraLambdaEvent) error {
+	line, err := json.Marshal(RecordedInvocation{
+		RequestID: RequestID(ctx),
+		Time:      time.Now().UTC(),
+		Event:     *event,
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err = r.w.Write(append(line, '\n'))
+	return err
+}
+
+// recordEvent records event when WithRecorder is set
+func (w *CobraLambda) recordEvent(ctx context.Context, event *CobraLambdaEvent) {
+	if w.recorder == nil {
+		return
+	}
+
+	if err := w.recorder.record(ctx, event); err != nil {
+		_, _ = fmt.Fprintf(w.originalStderr, "wrapper: recording event: %v\n", err)
+	}
+}
+
+// ReplayResult is the outcome of replaying a recorded invocation
+type ReplayResult struct {
+	Invocation RecordedInvocation
+	Output     *CobraLambdaOutput
+	Err        error
+}
+
+// Replay runs every invocation recorded by WithRecorder in r through handler in
+// order, with the recorded request ID available from RequestID. Blank lines are
+// skipped and a line that cannot be decoded stops the replay with an error
+// naming its 1-based line number, returning the results so far
+func Replay(ctx context.Context, r io.Reader, handler CobraLambdaTypedFunc) ([]ReplayResult, error) {
+	results := []ReplayResult{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 6<<20)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		invocation := RecordedInvocation{}
+		if err := json.Unmarshal([]byte(line), &invocation); err != nil {
+			return results, fmt.Errorf("wrapper: replay line %d: %w", n, err)
+		}
+
+		if invocation.Event.Args == nil {
+			invocation.Event.Args = []string{}
+		}
+
+		invokeCtx := ctx
+		if invocation.RequestID != "" {
+			invokeCtx = lambdacontext.NewContext(ctx, &lambdacontext.LambdaContext{AwsRequestID: invocation.RequestID})
+		}
+
+		output, err := handler(invokeCtx, *invocation.Event.clone())
+		results = append(results, ReplayResult{Invocation: invocation, Output: output, Err: err})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return results, fmt.Errorf("wrapper: replay: %w", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,92 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/spf13/cobra"
+)
+
+func TestRecorderReplay(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 && args[0] == "fail" {
+				return errors.New("command failed")
+			}
+			cmd.Printf("%s %s", RequestID(cmd.Context()), strings.Join(args, " "))
+			return nil
+		},
+	}
+
+	recording := &bytes.Buffer{}
+	handler := NewTypedHandler(cmd, WithoutMirror(), WithRecorder(recording))
+
+	events := []struct {
+		requestID string
+		event     CobraLambdaEvent
+	}{
+		{"req-1", CobraLambdaEvent{Args: []string{"greet", "hello world"}}},
+		{"req-2", CobraLambdaEvent{Args: []string{"fail"}, Context: map[string]string{"tenant": "acme"}}},
+	}
+
+	for _, e := range events {
+		ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: e.requestID})
+		_, _ = handler(ctx, e.event)
+	}
+
+	lines := strings.Split(strings.TrimSpace(recording.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 recorded lines, got: %q", recording.String())
+	}
+
+	recorded := RecordedInvocation{}
+	if err := json.Unmarshal([]byte(lines[1]), &recorded); err != nil {
+		t.Fatalf("Failed to decode recorded line: %v", err)
+	}
+	if recorded.RequestID != "req-2" || recorded.Time.IsZero() || recorded.Event.Context["tenant"] != "acme" {
+		t.Errorf("Unexpected recorded invocation: %+v", recorded)
+	}
+
+	// replay through a fresh handler without a recorder
+	results, err := Replay(context.Background(), recording, NewTypedHandler(cmd, WithoutMirror()))
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got: %d", len(results))
+	}
+	if results[0].Err != nil || results[0].Output.Stdout != "req-1 greet hello world" {
+		t.Errorf("Unexpected first result: %+v", results[0])
+	}
+	if results[1].Err == nil || results[1].Err.Error() != "command failed" {
+		t.Errorf("Expected the recorded failure to be reproduced, got: %v", results[1].Err)
+	}
+	if results[1].Invocation.RequestID != "req-2" {
+		t.Errorf("Got: %s", results[1].Invocation.RequestID)
+	}
+}
+
+func TestReplay_InvalidLine(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
+
+	recording := strings.NewReader(`{"requestId":"req-1","time":"2024-01-01T00:00:00Z","event":{"args":["greet"]}}` + "\n\nnot json\n")
+
+	results, err := Replay(context.Background(), recording, NewTypedHandler(cmd, WithoutMirror()))
+
+	if err == nil || !strings.Contains(err.Error(), "wrapper: replay line 3") {
+		t.Errorf("Expected error naming line 3, got: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected the results before the invalid line, got: %d", len(results))
+	}
+}
@@ -0,0 +1,86 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// ErrReentrantExecution is returned when a command calls Execute or
+// ExecuteContext on a wrapper of the tree that is running it, for example when
+// the same command is reused across handlers. Waiting for the running
+// execution would deadlock
+var ErrReentrantExecution = errors.New("wrapper: Execute called from the command it is running")
+
+type runningKey struct{}
+
+// runningMark is carried by the context of a running command, identifying the
+// tree it runs on until the command returns
+type runningMark struct {
+	lock    *sync.Mutex
+	unwound <-chan struct{}
+}
+
+// checkReentrant fails when called from the command running on this wrapper's
+// tree. A ctx passed to ExecuteContext is recognised as derived from the
+// running command's context on any goroutine, so commands calling back into
+// the wrapper should use ExecuteContext(cmd.Context(), ...). Execute has no
+// context to check and only detects calls from the goroutine running the
+// command, when called from a goroutine the command started it waits for the
+// command and deadlocks if the command waits for it in turn. Concurrent calls
+// from elsewhere are serialized as usual
+func (w *CobraLambda) checkReentrant(ctx context.Context) error {
+	reentrant := false
+	if ctx != nil {
+		if mark, ok := ctx.Value(runningKey{}).(*runningMark); ok && mark.lock == w.mu {
+			select {
+			case <-mark.unwound:
+			default:
+				reentrant = true
+			}
+		}
+	}
+
+	if running := w.runningGoroutine.Load(); running != 0 && running == goroutineID() {
+		reentrant = true
+	}
+
+	if !reentrant {
+		return nil
+	}
+
+	_, _ = fmt.Fprintln(w.originalStderr, "wrapper: warning: Execute called re-entrantly from the running command")
+	return ErrReentrantExecution
+}
+
+// markRunning records the calling goroutine as the one running the command and
+// returns a func clearing it. Clearing is skipped if a later execution has
+// already taken over, as happens when an abandoned command finally returns
+func (w *CobraLambda) markRunning() func() {
+	id := goroutineID()
+	w.runningGoroutine.Store(id)
+	return func() {
+		w.runningGoroutine.CompareAndSwap(id, 0)
+	}
+}
+
+// goroutineID returns the id of the calling goroutine, parsed from the
+// "goroutine N [running]:" header of its stack trace. The runtime offers no
+// supported way to get it, which is why Execute without a context is only
+// checked on a best effort basis
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+
+	header := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(header, ' '); i > 0 {
+		header = header[:i]
+	}
+
+	id, _ := strconv.ParseUint(string(header), 10, 64)
+	return id
+}
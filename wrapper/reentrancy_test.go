@@ -0,0 +1,105 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_ReentrantExecute(t *testing.T) {
+	var wrapper *CobraLambda
+	var nestedErr error
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 0 {
+				cmd.Print("nested")
+				return
+			}
+			_, nestedErr = wrapper.Execute([]string{"again"})
+			cmd.Print("outer")
+		},
+	}
+
+	wrapper = NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror())
+
+	done := make(chan *CobraLambdaOutput, 1)
+	go func() {
+		output, err := wrapper.Execute([]string{})
+		if err != nil {
+			t.Errorf("Execute failed: %v", err)
+		}
+		done <- output
+	}()
+
+	select {
+	case output := <-done:
+		if !errors.Is(nestedErr, ErrReentrantExecution) {
+			t.Errorf("Expected ErrReentrantExecution from the nested call, got: %v", nestedErr)
+		}
+		if output != nil && output.Stdout != "outer" {
+			t.Errorf("Got: %s", output.Stdout)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Re-entrant Execute deadlocked")
+	}
+
+	// the wrapper stays usable once the outer execution has finished
+	output, err := wrapper.Execute([]string{"again"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.Stdout != "nested" {
+		t.Errorf("Got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_ReentrantExecuteContextFromGoroutine(t *testing.T) {
+	var wrapper *CobraLambda
+	var nestedErr error
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			nested := make(chan struct{})
+			go func() {
+				defer close(nested)
+				_, nestedErr = wrapper.ExecuteContext(cmd.Context(), []string{"again"})
+			}()
+			<-nested
+			cmd.Print("outer")
+		},
+	}
+
+	wrapper = NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror())
+
+	done := make(chan *CobraLambdaOutput, 1)
+	go func() {
+		output, err := wrapper.Execute([]string{})
+		if err != nil {
+			t.Errorf("Execute failed: %v", err)
+		}
+		done <- output
+	}()
+
+	select {
+	case output := <-done:
+		if !errors.Is(nestedErr, ErrReentrantExecution) {
+			t.Errorf("Expected ErrReentrantExecution from the nested call, got: %v", nestedErr)
+		}
+		if output != nil && output.Stdout != "outer" {
+			t.Errorf("Got: %s", output.Stdout)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Re-entrant ExecuteContext from a command goroutine deadlocked")
+	}
+
+	// a context outliving the execution it came from no longer counts as re-entrant
+	if _, err := wrapper.ExecuteContext(context.TODO(), []string{}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+}
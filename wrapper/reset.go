@@ -0,0 +1,127 @@
+package wrapper
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ErrUnresettableFlag is returned when the command tree has a flag whose value
+// cannot be restored to its default between executions, such as the map flags
+// of StringToStringVar, which merge into their map once set
+var ErrUnresettableFlag = errors.New("wrapper: flag cannot be reset between executions")
+
+// resetCommand restores every flag in the command tree to its default value so
+// state from a previous execution does not leak into the next one.
+//
+// Slice flags such as StringArrayVar append to their value once set, so
+// setting the default back would leave the next execution appending to it.
+// They are wrapped the first time they are reset so their first value after a
+// reset replaces the default again. Map flags such as StringToStringVar merge
+// into their map the same way but offer no way to replace it, so they are
+// rejected with ErrUnresettableFlag before any execution sets them
+func resetCommand(cmd *cobra.Command) error {
+	var err error
+
+	reset := func(f *pflag.Flag) {
+		if strings.HasPrefix(f.Value.Type(), "stringTo") {
+			if err == nil {
+				err = fmt.Errorf("%w: --%s of %q is a %s flag, use a slice flag such as StringArrayVar instead", ErrUnresettableFlag, f.Name, cmd.CommandPath(), f.Value.Type())
+			}
+			return
+		}
+
+		if slice, ok := f.Value.(*resettableSlice); ok {
+			if f.Changed || slice.changed {
+				slice.reset()
+			}
+			f.Changed = false
+			return
+		}
+
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			wrapped := &resettableSlice{Value: f.Value, slice: slice, defaults: parseSliceDefault(f.DefValue)}
+			f.Value = wrapped
+			if f.Changed {
+				wrapped.reset()
+			}
+			f.Changed = false
+			return
+		}
+
+		if !f.Changed {
+			return
+		}
+		_ = f.Value.Set(f.DefValue)
+		f.Changed = false
+	}
+
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+
+	for _, sub := range cmd.Commands() {
+		if subErr := resetCommand(sub); err == nil {
+			err = subErr
+		}
+	}
+
+	return err
+}
+
+// parseSliceDefault parses the default of a slice flag as formatted by pflag,
+// e.g. [a,b] or ["a,b",c]
+func parseSliceDefault(def string) []string {
+	def = strings.TrimSuffix(strings.TrimPrefix(def, "["), "]")
+	if def == "" {
+		return []string{}
+	}
+
+	values, err := csv.NewReader(strings.NewReader(def)).Read()
+	if err != nil {
+		return strings.Split(def, ",")
+	}
+	return values
+}
+
+// resettableSlice wraps a pflag slice value so the first Set after a reset
+// replaces the value instead of appending to the default
+type resettableSlice struct {
+	pflag.Value
+	slice    pflag.SliceValue
+	defaults []string
+	changed  bool
+}
+
+func (r *resettableSlice) Set(val string) error {
+	if !r.changed {
+		// the wrapped value appends once it has ever been set
+		if err := r.slice.Replace([]string{}); err != nil {
+			return err
+		}
+		r.changed = true
+	}
+	return r.Value.Set(val)
+}
+
+func (r *resettableSlice) reset() {
+	_ = r.slice.Replace(r.defaults)
+	r.changed = false
+}
+
+func (r *resettableSlice) Append(val string) error {
+	r.changed = true
+	return r.slice.Append(val)
+}
+
+func (r *resettableSlice) Replace(val []string) error {
+	r.changed = true
+	return r.slice.Replace(val)
+}
+
+func (r *resettableSlice) GetSlice() []string {
+	return r.slice.GetSlice()
+}
@@ -0,0 +1,115 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_ResetFlagsBetweenRuns(t *testing.T) {
+	var (
+		name    string
+		verbose bool
+		count   int
+		tags    []string
+		labels  []string
+	)
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("name=%s verbose=%v count=%d tags=%v labels=%v", name, verbose, count, tags, labels)
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "World", "")
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "")
+	cmd.Flags().IntVar(&count, "count", 1, "")
+	cmd.Flags().StringArrayVar(&tags, "tag", []string{"default"}, "")
+	cmd.Flags().StringSliceVar(&labels, "label", []string{}, "")
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror())
+
+	runs := []struct {
+		args []string
+		want string
+	}{
+		{
+			args: []string{"--name", "bob", "--verbose", "--count", "3", "--tag", "a", "--tag", "b", "--label", "x,y"},
+			want: "name=bob verbose=true count=3 tags=[a b] labels=[x y]",
+		},
+		{
+			args: []string{},
+			want: "name=World verbose=false count=1 tags=[default] labels=[]",
+		},
+		{
+			args: []string{"--tag", "c", "--label", "z"},
+			want: "name=World verbose=false count=1 tags=[c] labels=[z]",
+		},
+		{
+			args: []string{"--tag", "d"},
+			want: "name=World verbose=false count=1 tags=[d] labels=[]",
+		},
+	}
+
+	for i, run := range runs {
+		output, err := wrapper.Execute(run.args)
+		if err != nil {
+			t.Fatalf("Execute %d failed: %v", i+1, err)
+		}
+
+		if output.Stdout != run.want {
+			t.Errorf("Run %d expected %q, got: %s", i+1, run.want, output.Stdout)
+		}
+	}
+
+	if got, _ := cmd.Flags().GetStringArray("tag"); len(got) != 1 || got[0] != "d" {
+		t.Errorf("Expected GetStringArray to see the wrapped value, got %v", got)
+	}
+}
+
+func TestCobraWrapper_RejectMapFlags(t *testing.T) {
+	var opts map[string]string
+
+	root := &cobra.Command{Use: "root"}
+	sub := &cobra.Command{
+		Use: "sub",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("opts=%v", opts)
+		},
+	}
+	sub.Flags().StringToStringVar(&opts, "opt", nil, "")
+	root.AddCommand(sub)
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), root, WithoutMirror())
+
+	output, err := wrapper.Execute([]string{"sub", "--opt", "a=1"})
+	if !errors.Is(err, ErrUnresettableFlag) {
+		t.Fatalf("Expected ErrUnresettableFlag, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), `--opt of "root sub"`) {
+		t.Errorf("Expected the error to name the flag, got: %v", err)
+	}
+	if output != nil || opts != nil {
+		t.Errorf("Expected the command not to run, got output %v and opts %v", output, opts)
+	}
+}
+
+func TestParseSliceDefault(t *testing.T) {
+	tests := map[string][]string{
+		"[]":        {},
+		"[a]":       {"a"},
+		"[a,b]":     {"a", "b"},
+		`["a,b",c]`: {"a,b", "c"},
+	}
+
+	for def, want := range tests {
+		got := parseSliceDefault(def)
+		if fmt.Sprint(got) != fmt.Sprint(want) || len(got) != len(want) {
+			t.Errorf("parseSliceDefault(%q) = %q, want %q", def, got, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package wrapper
+
+import (
+	"context"
+	"time"
+)
+
+// retryPolicy decides whether and when a failed execution is retried
+type retryPolicy struct {
+	maxAttempts int
+	retryable   func(error) bool
+	backoff     func(attempt int) time.Duration
+}
+
+// WithRetry runs the command again, up to maxAttempts executions in total, when
+// it returns an error retryable reports true for, e.g. a transient downstream
+// failure. backoff returns how long to wait after the given 1-based attempt and
+// may be nil to retry right away. Flags are reset and output is captured afresh
+// for every attempt, so only the output of the last one is returned. Output
+// already streamed by ExecuteStream cannot be taken back. Executions that timed
+// out or whose context is done are not retried
+func WithRetry(maxAttempts int, retryable func(error) bool, backoff func(attempt int) time.Duration) Option {
+	return func(w *CobraLambda) {
+		w.retry = &retryPolicy{
+			maxAttempts: maxAttempts,
+			retryable:   retryable,
+			backoff:     backoff,
+		}
+	}
+}
+
+// shouldRetry reports whether the execution that produced output and err should
+// be attempted again. Errors returned before the command ran are not retried
+func (w *CobraLambda) shouldRetry(ctx context.Context, attempt int, output *CobraLambdaOutput, err error) bool {
+	if w.retry == nil || err == nil || output == nil || output.TimedOut {
+		return false
+	}
+
+	if attempt >= w.retry.maxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	return w.retry.retryable != nil && w.retry.retryable(err)
+}
+
+// waitRetry sleeps for the backoff after attempt, returning early with the
+// context error if the execution context is done first
+func (w *CobraLambda) waitRetry(ctx context.Context, attempt int) error {
+	if w.retry.backoff == nil {
+		return nil
+	}
+
+	d := w.retry.backoff(attempt)
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,136 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var errTransient = errors.New("transient failure")
+
+func isTransient(err error) bool {
+	return errors.Is(err, errTransient)
+}
+
+func TestCobraWrapper_RetrySucceeds(t *testing.T) {
+	attempts := 0
+	var verbose bool
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			attempts++
+			fmt.Printf("attempt %d verbose=%t\n", attempts, verbose)
+			if attempts <= 2 {
+				// flag state left by a failed attempt must be reset before the retry
+				_ = cmd.Flags().Set("verbose", "true")
+				return errTransient
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "Verbose output")
+
+	var waits []int
+	backoff := func(attempt int) time.Duration {
+		waits = append(waits, attempt)
+		return time.Millisecond
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithRetry(3, isTransient, backoff))
+	output, err := wrapper.Execute([]string{})
+
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got: %d", attempts)
+	}
+	if output.Stdout != "attempt 3 verbose=false\n" {
+		t.Errorf("Expected only the output of the last attempt with reset flags. Got: %q", output.Stdout)
+	}
+	if output.ExitCode != 0 {
+		t.Errorf("Expected exit code 0, got: %d", output.ExitCode)
+	}
+	if len(waits) != 2 || waits[0] != 1 || waits[1] != 2 {
+		t.Errorf("Expected backoff after attempts 1 and 2, got: %v", waits)
+	}
+}
+
+func TestCobraWrapper_RetryExhausted(t *testing.T) {
+	attempts := 0
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			attempts++
+			return errTransient
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithRetry(2, isTransient, nil))
+	output, err := wrapper.Execute([]string{})
+
+	if !errors.Is(err, errTransient) {
+		t.Fatalf("Expected errTransient, got: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got: %d", attempts)
+	}
+	if output.ExitCode != 1 {
+		t.Errorf("Expected exit code 1, got: %d", output.ExitCode)
+	}
+}
+
+func TestCobraWrapper_RetryNotRetryable(t *testing.T) {
+	attempts := 0
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			attempts++
+			return errors.New("permanent failure")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithRetry(3, isTransient, nil))
+	if _, err := wrapper.Execute([]string{}); err == nil {
+		t.Fatal("Expected error but got none")
+	}
+	if attempts != 1 {
+		t.Errorf("Expected a single attempt, got: %d", attempts)
+	}
+}
+
+func TestCobraWrapper_RetryBackoffCancelled(t *testing.T) {
+	attempts := 0
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			attempts++
+			return errTransient
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	backoff := func(attempt int) time.Duration {
+		cancel()
+		return time.Minute
+	}
+
+	wrapper := NewCobraLambdaCLI(ctx, cmd, WithoutMirror(), WithRetry(3, isTransient, backoff))
+
+	start := time.Now()
+	_, err := wrapper.Execute([]string{})
+
+	if !errors.Is(err, errTransient) {
+		t.Fatalf("Expected the last command error, got: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected no retry after cancellation, got: %d attempts", attempts)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("Expected the backoff to stop on cancellation")
+	}
+}
@@ -0,0 +1,59 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShuttingDown is returned by executions started after Shutdown was called
+var ErrShuttingDown = errors.New("wrapper: shutting down")
+
+var (
+	shutdownMu   sync.Mutex
+	shuttingDown bool
+	// activeExecutions tracks Execute and ExecuteStream calls across every
+	// wrapper and handler in the process
+	activeExecutions = &sync.WaitGroup{}
+)
+
+// trackExecution registers an execution for Shutdown to wait on, returning the
+// function marking it finished. It fails with ErrShuttingDown once Shutdown
+// has been called
+func trackExecution() (func(), error) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+
+	if shuttingDown {
+		return nil, ErrShuttingDown
+	}
+
+	activeExecutions.Add(1)
+	return activeExecutions.Done, nil
+}
+
+// Shutdown waits for executions in flight across every wrapper and handler in
+// the process to finish, including flushing ExecuteStream output, e.g. when
+// the Lambda runtime sends SIGTERM to a function registering a handler with
+// lambda.WithEnableSIGTERM. Executions started after Shutdown is called
+// fail with ErrShuttingDown. It returns ctx.Err() when ctx is done before the
+// executions finish, which are left running
+func Shutdown(ctx context.Context) error {
+	shutdownMu.Lock()
+	shuttingDown = true
+	active := activeExecutions
+	shutdownMu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		active.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
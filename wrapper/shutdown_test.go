@@ -0,0 +1,151 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// allowExecutionsAfterShutdown undoes Shutdown once the test has finished so
+// later tests can execute commands again. Executions are tracked in a new
+// WaitGroup as a Wait left behind by a Shutdown that timed out may not have
+// returned yet
+func allowExecutionsAfterShutdown(t *testing.T) {
+	t.Cleanup(func() {
+		shutdownMu.Lock()
+		shuttingDown = false
+		activeExecutions = &sync.WaitGroup{}
+		shutdownMu.Unlock()
+	})
+}
+
+func TestShutdown_WaitsForInFlightExecution(t *testing.T) {
+	allowExecutionsAfterShutdown(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			close(started)
+			<-release
+			fmt.Print("finished")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror())
+
+	type result struct {
+		output *CobraLambdaOutput
+		err    error
+	}
+	results := make(chan result, 1)
+	go func() {
+		output, err := wrapper.Execute([]string{})
+		results <- result{output, err}
+	}()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- Shutdown(context.Background())
+	}()
+
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown returned before the execution finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// executions started during shutdown are rejected
+	other := NewCobraLambdaCLI(context.TODO(), &cobra.Command{Use: "other", Run: func(*cobra.Command, []string) {}}, WithoutMirror())
+	if _, err := other.Execute([]string{}); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Expected ErrShuttingDown, got %v", err)
+	}
+
+	close(release)
+
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("Expected Shutdown to succeed, got %v", err)
+	}
+
+	res := <-results
+	if res.err != nil || res.output.Stdout != "finished" {
+		t.Errorf("Expected the in-flight execution to complete, got %+v, %v", res.output, res.err)
+	}
+}
+
+func TestShutdown_ContextDeadline(t *testing.T) {
+	allowExecutionsAfterShutdown(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			close(started)
+			<-release
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror())
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		_, _ = wrapper.Execute([]string{})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	close(release)
+	<-finished
+}
+
+func TestShutdown_WaitsForStreamFlush(t *testing.T) {
+	allowExecutionsAfterShutdown(t)
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Print("buffered")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithFlushSize(1024))
+	reader, errc := wrapper.ExecuteStream([]string{})
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- Shutdown(context.Background())
+	}()
+
+	// the final flush blocks until read, Shutdown must wait for it
+	data, err := io.ReadAll(reader)
+	if err != nil || string(data) != "buffered" {
+		t.Fatalf("Expected flushed output, got %q, %v", data, err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("Expected Shutdown to succeed, got %v", err)
+	}
+
+	_, errc = wrapper.ExecuteStream([]string{})
+	if err := <-errc; !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Expected ErrShuttingDown for a stream started after Shutdown, got %v", err)
+	}
+}
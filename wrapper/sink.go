@@ -0,0 +1,69 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrSink is returned by Execute when writing captured output to the sink set
+// with WithSink failed
+var ErrSink = errors.New("wrapper: writing to output sink failed")
+
+// WithSink writes captured output to sink as it is produced instead of holding
+// it in memory, for streaming large output to S3 or a socket. Stdout is left
+// empty and OutputBytes counts only what was returned. The sink takes precedence over
+// WithOutputFile and receives output before redaction and other
+// post-processing. Once a write fails the rest of the output is discarded and
+// Execute returns ErrSink
+func WithSink(sink io.Writer) Option {
+	return func(w *CobraLambda) {
+		w.sink = sink
+	}
+}
+
+// newSinkWriter returns nil when no sink is set
+func (w *CobraLambda) newSinkWriter() *sinkWriter {
+	if w.sink == nil {
+		return nil
+	}
+	return &sinkWriter{dst: w.sink}
+}
+
+// sinkWriter records the first error writing to dst and discards later writes,
+// so the capture pipes keep draining. It is shared by the stdout and stderr
+// drain goroutines
+type sinkWriter struct {
+	mu     sync.Mutex
+	dst    io.Writer
+	err    error
+	failed bool
+}
+
+func (s *sinkWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed {
+		return len(p), nil
+	}
+
+	if _, err := s.dst.Write(p); err != nil {
+		s.failed = true
+		s.err = fmt.Errorf("%w: %w", ErrSink, err)
+	}
+
+	return len(p), nil
+}
+
+// Err returns the first write error, if any
+func (s *sinkWriter) Err() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
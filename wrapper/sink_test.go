@@ -0,0 +1,80 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_WithSink(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			for i := range 3 {
+				fmt.Printf("line %d\n", i)
+			}
+			fmt.Fprint(os.Stderr, "warning\n")
+		},
+	}
+
+	sink := &bytes.Buffer{}
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithSink(sink))
+
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	// stdout and stderr are drained separately so only the order within each is kept
+	got := sink.String()
+	if !strings.Contains(got, "line 0\nline 1\nline 2\n") || !strings.Contains(got, "warning\n") {
+		t.Errorf("Expected the sink to receive all output, got: %q", got)
+	}
+
+	if output.Stdout != "" || output.OutputBytes != 0 {
+		t.Errorf("Expected no output held in memory, got: %q", output.Stdout)
+	}
+}
+
+// failingWriter fails every write after the first
+type failingWriter struct {
+	writes int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	f.writes++
+	if f.writes > 1 {
+		return 0, errors.New("connection reset")
+	}
+	return len(p), nil
+}
+
+func TestCobraWrapper_WithSinkFailing(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			// more than a pipe buffer, so a stalled drain would block the command
+			for range 10000 {
+				fmt.Println("some output to stream somewhere")
+			}
+		},
+	}
+
+	sink := &failingWriter{}
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithSink(sink))
+
+	_, err := wrapper.Execute([]string{})
+	if !errors.Is(err, ErrSink) {
+		t.Fatalf("Expected ErrSink, got %v", err)
+	}
+
+	if sink.writes != 2 {
+		t.Errorf("Expected writes to stop after the failure, got %d writes", sink.writes)
+	}
+}
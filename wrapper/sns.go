@@ -0,0 +1,33 @@
+package wrapper
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/spf13/cobra"
+)
+
+type SNSFunc func(ctx context.Context, event events.SNSEvent) (*BatchOutput, error)
+
+// NewSNSHandler returns a handler that runs cmd once per SNS record, decoding each
+// message as a CobraLambdaEvent. Flags are reset between records
+func NewSNSHandler(cmd *cobra.Command, opts ...Option) SNSFunc {
+	return func(ctx context.Context, event events.SNSEvent) (*BatchOutput, error) {
+		if cmd == nil {
+			return nil, ErrNilCommand
+		}
+
+		lambda := newCobraLambda(ctx, cmd, opts...)
+
+		batch := &BatchOutput{
+			Results: make([]RecordResult, 0, len(event.Records)),
+		}
+
+		for _, record := range event.Records {
+			result := lambda.executeRecord(ctx, record.SNS.MessageID, []byte(record.SNS.Message))
+			batch.Results = append(batch.Results, result)
+		}
+
+		return batch, nil
+	}
+}
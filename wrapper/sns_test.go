@@ -0,0 +1,105 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/spf13/cobra"
+)
+
+var errFailed = errors.New("record failed")
+
+func snsRecord(id, message string) events.SNSEventRecord {
+	return events.SNSEventRecord{
+		SNS: events.SNSEntity{
+			MessageID: id,
+			Message:   message,
+		},
+	}
+}
+
+func TestNewSNSHandler_MultipleRecords(t *testing.T) {
+	var name string
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Printf("Hello, %s!\n", name)
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "World", "Name to greet")
+
+	handler := NewSNSHandler(cmd)
+
+	event := events.SNSEvent{
+		Records: []events.SNSEventRecord{
+			snsRecord("msg-1", `{"args": ["--name", "Alice"]}`),
+			snsRecord("msg-2", `{"args": [invalid`),
+			snsRecord("msg-3", `{"args": []}`),
+		},
+	}
+
+	output, err := handler(context.Background(), event)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if len(output.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(output.Results))
+	}
+
+	first := output.Results[0]
+	if first.ID != "msg-1" || first.Error != "" {
+		t.Errorf("Unexpected first result: %+v", first)
+	}
+	if first.Output == nil || !strings.Contains(first.Output.Stdout, "Hello, Alice!") {
+		t.Errorf("Expected greeting for Alice, got: %+v", first.Output)
+	}
+
+	malformed := output.Results[1]
+	if malformed.ID != "msg-2" || malformed.Error == "" || malformed.Output != nil {
+		t.Errorf("Expected error entry for malformed message, got: %+v", malformed)
+	}
+
+	// Flag state must not leak from the first record
+	third := output.Results[2]
+	if third.Output == nil || !strings.Contains(third.Output.Stdout, "Hello, World!") {
+		t.Errorf("Expected flags to reset between records, got: %+v", third.Output)
+	}
+}
+
+func TestNewSNSHandler_CommandError(t *testing.T) {
+	cmd := &cobra.Command{
+		Use:           "test",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 && args[0] == "fail" {
+				return errFailed
+			}
+			cmd.Println("ok")
+			return nil
+		},
+	}
+
+	handler := NewSNSHandler(cmd)
+
+	output, err := handler(context.Background(), events.SNSEvent{
+		Records: []events.SNSEventRecord{
+			snsRecord("msg-1", `{"args": ["fail"]}`),
+			snsRecord("msg-2", `{"args": []}`),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if output.Results[0].Error != errFailed.Error() {
+		t.Errorf("Expected command error on first record, got: %+v", output.Results[0])
+	}
+	if output.Results[1].Error != "" || !strings.Contains(output.Results[1].Output.Stdout, "ok") {
+		t.Errorf("Expected second record to succeed, got: %+v", output.Results[1])
+	}
+}
@@ -0,0 +1,92 @@
+package wrapper
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// OutputSources counts captured output by the writer it came through, set when
+// WithSourceTracking is used. Stdout and stderr are counted together
+type OutputSources struct {
+	// CobraWrites and CobraBytes count writes through cmd.OutOrStdout(),
+	// cmd.ErrOrStderr() and the cmd.Print family
+	CobraWrites int64 `json:"cobraWrites"`
+	CobraBytes  int64 `json:"cobraBytes"`
+	// OSBytes counts bytes written straight to os.Stdout and os.Stderr, e.g. with
+	// fmt.Println, bypassing cobra's writers
+	OSBytes int64 `json:"osBytes"`
+}
+
+// WithSourceTracking records in CobraLambdaOutput.Sources how much output came
+// through cobra's writers and how much was written to os.Stdout and os.Stderr
+// directly, to find commands bypassing cobra's output. Cobra's out and err
+// writers are set on the wrapped command for the execution, so cmd.Print and
+// cmd.Println write to stdout instead of stderr while tracking
+func WithSourceTracking() Option {
+	return func(w *CobraLambda) {
+		w.sourceTracking = true
+	}
+}
+
+// sourceTracker counts cobra writes and everything read from the capture pipes,
+// the difference being output written to os.Stdout/os.Stderr directly. It is
+// safe for concurrent use as a command that timed out may still be writing
+type sourceTracker struct {
+	cobraWrites atomic.Int64
+	cobraBytes  atomic.Int64
+	pipeBytes   atomic.Int64
+}
+
+// newSourceTracker returns nil when source tracking is disabled
+func (w *CobraLambda) newSourceTracker() *sourceTracker {
+	if !w.sourceTracking {
+		return nil
+	}
+	return &sourceTracker{}
+}
+
+// cobraWriter counts writes to dst made through cobra
+func (t *sourceTracker) cobraWriter(dst io.Writer) io.Writer {
+	return &countingWriter{dst: dst, tracker: t}
+}
+
+// pipeReader counts bytes read from a capture pipe. A nil tracker returns r
+func (t *sourceTracker) pipeReader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &countingReader{src: r, tracker: t}
+}
+
+func (t *sourceTracker) sources() *OutputSources {
+	cobraBytes := t.cobraBytes.Load()
+
+	return &OutputSources{
+		CobraWrites: t.cobraWrites.Load(),
+		CobraBytes:  cobraBytes,
+		OSBytes:     max(t.pipeBytes.Load()-cobraBytes, 0),
+	}
+}
+
+type countingWriter struct {
+	dst     io.Writer
+	tracker *sourceTracker
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.dst.Write(p)
+	c.tracker.cobraWrites.Add(1)
+	c.tracker.cobraBytes.Add(int64(n))
+	return n, err
+}
+
+type countingReader struct {
+	src     io.Reader
+	tracker *sourceTracker
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.src.Read(p)
+	c.tracker.pipeBytes.Add(int64(n))
+	return n, err
+}
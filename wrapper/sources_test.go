@@ -0,0 +1,62 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_SourceTracking(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Fprint(cmd.OutOrStdout(), "cobra\n")
+			cmd.PrintErr("err\n")
+			fmt.Print("raw\n")
+			fmt.Fprint(os.Stderr, "raw err\n")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithSourceTracking())
+
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	want := OutputSources{CobraWrites: 2, CobraBytes: 10, OSBytes: 12}
+	if output.Sources == nil || *output.Sources != want {
+		t.Errorf("Expected sources %+v, got %+v", want, output.Sources)
+	}
+
+	if len(output.Stdout) != 22 {
+		t.Errorf("Expected all output captured, got: %q", output.Stdout)
+	}
+
+	if cmd.OutOrStdout() != os.Stdout {
+		t.Error("Expected the tracking writers to be cleared after execution")
+	}
+}
+
+func TestCobraWrapper_SourceTrackingDisabled(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Print("result")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror())
+
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if output.Sources != nil {
+		t.Errorf("Expected no sources without tracking, got %+v", output.Sources)
+	}
+}
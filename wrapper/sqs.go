@@ -0,0 +1,81 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/spf13/cobra"
+)
+
+// SQSEncodingAttribute is the message attribute marking an SQS record body as
+// encoded, a value of "base64" has the body decoded before it is unmarshaled
+const SQSEncodingAttribute = "Content-Transfer-Encoding"
+
+// SQSOutput aggregates the results of every record alongside the message IDs of
+// failed records, reported as a partial batch response so only those are retried
+type SQSOutput struct {
+	BatchOutput
+	BatchItemFailures []events.SQSBatchItemFailure `json:"batchItemFailures"`
+}
+
+type SQSFunc func(ctx context.Context, event events.SQSEvent) (*SQSOutput, error)
+
+// NewSQSHandler returns a handler that runs cmd once per SQS record, decoding each
+// body as a CobraLambdaEvent. Bodies with a Content-Transfer-Encoding message
+// attribute of base64 are base64 decoded first. Records that fail to decode or
+// execute are reported in BatchItemFailures. Flags are reset between records
+func NewSQSHandler(cmd *cobra.Command, opts ...Option) SQSFunc {
+	return func(ctx context.Context, event events.SQSEvent) (*SQSOutput, error) {
+		if cmd == nil {
+			return nil, ErrNilCommand
+		}
+
+		lambda := newCobraLambda(ctx, cmd, opts...)
+
+		output := &SQSOutput{
+			BatchOutput: BatchOutput{
+				Results: make([]RecordResult, 0, len(event.Records)),
+			},
+			BatchItemFailures: []events.SQSBatchItemFailure{},
+		}
+
+		for _, record := range event.Records {
+			var result RecordResult
+			body, err := sqsBody(record)
+			if err != nil {
+				result = RecordResult{ID: record.MessageId, Error: err.Error()}
+			} else {
+				result = lambda.executeRecord(ctx, record.MessageId, body)
+			}
+
+			output.Results = append(output.Results, result)
+
+			if result.Error != "" {
+				output.BatchItemFailures = append(output.BatchItemFailures, events.SQSBatchItemFailure{
+					ItemIdentifier: record.MessageId,
+				})
+			}
+		}
+
+		return output, nil
+	}
+}
+
+// sqsBody returns the body of record, base64 decoded when its encoding
+// attribute says so
+func sqsBody(record events.SQSMessage) ([]byte, error) {
+	attribute, ok := record.MessageAttributes[SQSEncodingAttribute]
+	if !ok || attribute.StringValue == nil || !strings.EqualFold(*attribute.StringValue, EncodingBase64) {
+		return []byte(record.Body), nil
+	}
+
+	body, err := base64.StdEncoding.DecodeString(strings.TrimSpace(record.Body))
+	if err != nil {
+		return nil, fmt.Errorf("wrapper: decoding base64 body of message %s: %w", record.MessageId, err)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,75 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/spf13/cobra"
+)
+
+func sqsRecord(id, body, encoding string) events.SQSMessage {
+	record := events.SQSMessage{MessageId: id, Body: body}
+
+	if encoding != "" {
+		record.MessageAttributes = map[string]events.SQSMessageAttribute{
+			SQSEncodingAttribute: {DataType: "String", StringValue: &encoding},
+		}
+	}
+
+	return record
+}
+
+func TestNewSQSHandler_Base64Bodies(t *testing.T) {
+	var name string
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Printf("Hello, %s!\n", name)
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "World", "Name to greet")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(`{"args": ["--name", "Bob"]}`))
+
+	event := events.SQSEvent{
+		Records: []events.SQSMessage{
+			sqsRecord("msg-1", `{"args": ["--name", "Alice"]}`, ""),
+			sqsRecord("msg-2", encoded, "base64"),
+			sqsRecord("msg-3", encoded, "BASE64"),
+			sqsRecord("msg-4", "not base64!", "base64"),
+		},
+	}
+
+	output, err := NewSQSHandler(cmd, WithoutMirror())(context.Background(), event)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if len(output.Results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(output.Results))
+	}
+
+	for i, want := range []string{"Hello, Alice!", "Hello, Bob!", "Hello, Bob!"} {
+		result := output.Results[i]
+		if result.Error != "" || result.Output == nil || !strings.Contains(result.Output.Stdout, want) {
+			t.Errorf("Expected %q for %s, got: %+v", want, result.ID, result)
+		}
+	}
+
+	if !strings.Contains(output.Results[3].Error, "base64") {
+		t.Errorf("Expected a base64 decode error, got: %+v", output.Results[3])
+	}
+
+	if len(output.BatchItemFailures) != 1 || output.BatchItemFailures[0].ItemIdentifier != "msg-4" {
+		t.Errorf("Expected only msg-4 to fail, got: %+v", output.BatchItemFailures)
+	}
+}
+
+func TestNewSQSHandler_NilCommand(t *testing.T) {
+	if _, err := NewSQSHandler(nil)(context.Background(), events.SQSEvent{}); err != ErrNilCommand {
+		t.Errorf("Expected ErrNilCommand, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrStderrThreshold is returned when a command wrote more to stderr than
+// allowed by WithStderrFailThreshold
+var ErrStderrThreshold = errors.New("wrapper: stderr exceeded threshold")
+
+// WithSeparateStderr captures stderr on its own into CobraLambdaOutput.Stderr
+// instead of interleaving it with stdout in Stdout
+func WithSeparateStderr() Option {
+	return func(w *CobraLambda) {
+		w.separateStderr = true
+	}
+}
+
+// WithStderrFailThreshold fails the invocation with ErrStderrThreshold when the
+// command writes more than n bytes to stderr, tolerating a few warnings while
+// failing on large error dumps even if the command itself succeeded. It implies
+// WithSeparateStderr and both streams are returned either way
+func WithStderrFailThreshold(n int) Option {
+	return func(w *CobraLambda) {
+		w.separateStderr = true
+		w.stderrFailThreshold = n
+		w.stderrFailThresholdSet = true
+	}
+}
+
+// stderrCapture returns the buffer stderr is captured into when it is kept
+// separate and the writer to drain stderr to, both nil when it is not
+func (w *CobraLambda) stderrCapture(tee io.Writer) (*threadSafeBuffer, io.Writer) {
+	if !w.separateStderr {
+		return nil, nil
+	}
+
+	buffer := &threadSafeBuffer{}
+	if tee != nil {
+		return buffer, io.MultiWriter(buffer, tee)
+	}
+	return buffer, buffer
+}
+
+// checkStderrThreshold fails when more than the threshold was written to stderr
+func (w *CobraLambda) checkStderrThreshold(stderr string) error {
+	if !w.stderrFailThresholdSet || len(stderr) <= w.stderrFailThreshold {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %d bytes written, threshold is %d", ErrStderrThreshold, len(stderr), w.stderrFailThreshold)
+}
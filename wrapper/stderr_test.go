@@ -0,0 +1,84 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_SeparateStderr(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Print("result")
+			fmt.Fprint(os.Stderr, "wwww")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithSeparateStderr())
+
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.Stdout != "result" {
+		t.Errorf("Expected only stdout in Stdout, got: %q", output.Stdout)
+	}
+	if output.Stderr != "wwww" {
+		t.Errorf("Expected stderr in Stderr, got: %q", output.Stderr)
+	}
+}
+
+func TestCobraWrapper_StderrFailThreshold(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr int
+		fails  bool
+	}{
+		{name: "no stderr", stderr: 0},
+		{name: "below threshold", stderr: 9},
+		{name: "at threshold", stderr: 10},
+		{name: "above threshold", stderr: 11, fails: true},
+		{name: "large dump", stderr: 64 * 1024, fails: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{
+				Use: "test",
+				Run: func(cmd *cobra.Command, args []string) {
+					fmt.Print("result")
+					fmt.Fprint(os.Stderr, strings.Repeat("w", tt.stderr))
+				},
+			}
+
+			wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithStderrFailThreshold(10))
+
+			output, err := wrapper.Execute([]string{})
+
+			if tt.fails {
+				if !errors.Is(err, ErrStderrThreshold) {
+					t.Fatalf("Expected ErrStderrThreshold, got: %v", err)
+				}
+				if output.ExitCode != 1 {
+					t.Errorf("Expected exit code 1, got: %d", output.ExitCode)
+				}
+			} else if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+
+			// both streams are returned regardless of the outcome
+			if output.Stdout != "result" {
+				t.Errorf("Expected stdout to be returned, got: %q", output.Stdout)
+			}
+			if len(output.Stderr) != tt.stderr {
+				t.Errorf("Expected %d bytes of stderr, got: %d", tt.stderr, len(output.Stderr))
+			}
+		})
+	}
+}
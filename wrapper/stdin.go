@@ -0,0 +1,85 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type stdinKey struct{}
+
+// withStdin attaches the event's stdin to ctx for execute to hand to the command
+func withStdin(ctx context.Context, stdin string) context.Context {
+	if stdin == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, stdinKey{}, stdin)
+}
+
+// applyStdin sets the input of the command tree to the stdin carried by ctx,
+// if any, and returns a func restoring the default input
+func (w *CobraLambda) applyStdin(ctx context.Context) func() {
+	stdin, ok := ctx.Value(stdinKey{}).(string)
+	if !ok {
+		return func() {}
+	}
+
+	w.cmd.SetIn(strings.NewReader(stdin))
+	return func() {
+		w.cmd.SetIn(nil)
+	}
+}
+
+// WithInheritedStdin leaves os.Stdin pointing at the process stdin while the
+// command runs. By default os.Stdin is replaced so reads return the event's
+// stdin, or EOF when it carries none, rather than blocking on the Lambda
+// runtime's stdin
+func WithInheritedStdin() Option {
+	return func(w *CobraLambda) {
+		w.inheritStdin = true
+	}
+}
+
+// redirectStdin points os.Stdin at the stdin carried by ctx, or at an empty
+// input when there is none, and returns a func restoring it
+func (w *CobraLambda) redirectStdin(ctx context.Context) (func(), error) {
+	if w.inheritStdin {
+		return func() {}, nil
+	}
+
+	original := os.Stdin
+	stdin, _ := ctx.Value(stdinKey{}).(string)
+
+	if stdin == "" {
+		devNull, err := os.Open(os.DevNull)
+		if err != nil {
+			return nil, fmt.Errorf("wrapper: failed to open empty stdin: %w", err)
+		}
+
+		os.Stdin = devNull
+		return func() {
+			os.Stdin = original
+			_ = devNull.Close()
+		}, nil
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("wrapper: failed to create stdin pipe: %w", err)
+	}
+
+	// the write blocks once the pipe buffer is full until the command reads,
+	// closing the reader on restore unblocks it if the command never does
+	go func() {
+		_, _ = io.WriteString(writer, stdin)
+		_ = writer.Close()
+	}()
+
+	os.Stdin = reader
+	return func() {
+		os.Stdin = original
+		_ = reader.Close()
+	}, nil
+}
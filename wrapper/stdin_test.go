@@ -0,0 +1,136 @@
+package wrapper
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestNewCobrLambdaHandler_Stdin(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	root.AddCommand(&cobra.Command{
+		Use: "upper",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := io.ReadAll(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			cmd.Printf("read %q", data)
+			return nil
+		},
+	})
+
+	handler := NewTypedHandler(root, WithoutMirror())
+
+	output, err := handler(context.TODO(), CobraLambdaEvent{Args: []string{"upper"}, Stdin: "line one\nline two\n"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.Stdout != `read "line one\nline two\n"` {
+		t.Errorf("Got: %s", output.Stdout)
+	}
+
+	// stdin must not leak into the next invocation
+	if root.InOrStdin() != os.Stdin {
+		t.Error("Expected command input to be reset after execution")
+	}
+}
+
+func TestCobraWrapper_EmptyStdin(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("read %d bytes", len(data))
+			return nil
+		},
+	}
+
+	originalStdin := os.Stdin
+
+	// a wrapper created without a context gets the empty stdin too
+	for _, ctx := range []context.Context{context.TODO(), nil} {
+		wrapper := NewCobraLambdaCLI(ctx, cmd, WithoutMirror())
+
+		output, err := wrapper.Execute([]string{})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if output.Stdout != "read 0 bytes" {
+			t.Errorf("Got: %s", output.Stdout)
+		}
+
+		if os.Stdin != originalStdin {
+			t.Error("Expected os.Stdin to be restored after execution")
+		}
+	}
+}
+
+func TestNewCobrLambdaHandler_EventStdinOnOsStdin(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("read %q", data)
+			return nil
+		},
+	}
+
+	handler := NewTypedHandler(cmd, WithoutMirror())
+
+	output, err := handler(context.TODO(), CobraLambdaEvent{Stdin: "from the event"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.Stdout != `read "from the event"` {
+		t.Errorf("Got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_InheritedStdin(t *testing.T) {
+	var seen *os.File
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			seen = os.Stdin
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithInheritedStdin())
+
+	if _, err := wrapper.Execute([]string{}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if seen != os.Stdin {
+		t.Error("Expected the command to see the process stdin")
+	}
+}
+
+func TestCobraWrapper_StdinWithoutContext(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Printf("default input: %v", cmd.InOrStdin() == os.Stdin)
+		},
+	}
+
+	// a wrapper created without a context has no stdin to apply
+	wrapper := NewCobraLambdaCLI(nil, cmd, WithoutMirror(), WithInheritedStdin())
+
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.Stdout != "default input: true" {
+		t.Errorf("Got: %s", output.Stdout)
+	}
+}
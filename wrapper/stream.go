@@ -0,0 +1,210 @@
+package wrapper
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// newTicker is swapped out in tests to drive interval flushes with a fake clock
+var newTicker = func(d time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(d)
+	return ticker.C, ticker.Stop
+}
+
+// newTimer is swapped out in tests to fire the deadline flush with a fake clock
+var newTimer = func(d time.Duration) (<-chan time.Time, func()) {
+	timer := time.NewTimer(d)
+	return timer.C, func() { timer.Stop() }
+}
+
+// WithFlushInterval buffers streamed output and flushes it to the ExecuteStream
+// reader every d instead of on every write
+func WithFlushInterval(d time.Duration) Option {
+	return func(w *CobraLambda) {
+		w.flushInterval = d
+	}
+}
+
+// WithFlushSize buffers streamed output and flushes it to the ExecuteStream
+// reader once at least n bytes are pending
+func WithFlushSize(n int) Option {
+	return func(w *CobraLambda) {
+		w.flushSize = n
+	}
+}
+
+// ExecuteStream runs the Cobra command like Execute but delivers captured output
+// live through the returned reader. The error channel yields the command's final
+// error once execution has finished.
+// The reader must be drained or closed, otherwise the command blocks on output.
+// Output is forwarded on every write unless WithFlushInterval or WithFlushSize is set.
+// Buffered output is flushed once the deadline of the context the command runs
+// under is within the deadline margin, after which writes are forwarded
+// immediately, so the tail reaches the reader before Lambda ends the invocation
+func (w *CobraLambda) ExecuteStream(args []string) (io.ReadCloser, <-chan error) {
+	pr, pw := io.Pipe()
+	errc := make(chan error, 1)
+
+	done, err := trackExecution()
+	if err != nil {
+		_ = pw.Close()
+		errc <- err
+		close(errc)
+		return pr, errc
+	}
+
+	sw := &streamWriter{
+		dst:       pw,
+		flushSize: w.flushSize,
+		buffered:  w.flushSize > 0 || w.flushInterval > 0,
+	}
+
+	go func() {
+		defer done()
+
+		stop := func() {}
+		if w.flushInterval > 0 {
+			stop = sw.flushEvery(w.flushInterval)
+		}
+
+		// the deadline is the one of the context the command runs under
+		ctx := w.restingContext()
+
+		stopDeadline := func() {}
+		if sw.buffered {
+			if deadline, ok := ctx.Deadline(); ok {
+				stopDeadline = sw.flushAt(deadline.Add(-w.deadlineMargin))
+			}
+		}
+
+		_, err := w.execute(ctx, args, sw)
+
+		stopDeadline()
+		stop()
+		sw.Flush()
+		_ = pw.Close()
+		errc <- err
+		close(errc)
+	}()
+
+	return pr, errc
+}
+
+// streamWriter forwards writes to dst, optionally buffering them until flushed,
+// and silently discards them once dst fails, e.g. because the reading side of a
+// pipe has gone away, so capture into the shared buffer keeps working
+type streamWriter struct {
+	mu        sync.Mutex
+	dst       io.Writer
+	buf       bytes.Buffer
+	buffered  bool
+	flushSize int
+	closed    bool
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return len(p), nil
+	}
+
+	if !s.buffered {
+		s.writeLocked(p)
+		return len(p), nil
+	}
+
+	s.buf.Write(p)
+
+	if s.flushSize > 0 && s.buf.Len() >= s.flushSize {
+		s.flushLocked()
+	}
+
+	return len(p), nil
+}
+
+// Flush writes any buffered output to dst
+func (s *streamWriter) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flushLocked()
+}
+
+func (s *streamWriter) flushLocked() {
+	if s.buf.Len() == 0 {
+		return
+	}
+
+	s.writeLocked(s.buf.Bytes())
+	s.buf.Reset()
+}
+
+func (s *streamWriter) writeLocked(p []byte) {
+	if s.closed {
+		return
+	}
+
+	if _, err := s.dst.Write(p); err != nil {
+		s.closed = true
+	}
+}
+
+// unbuffer flushes any buffered output and forwards later writes immediately
+func (s *streamWriter) unbuffer() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flushLocked()
+	s.buffered = false
+}
+
+// flushAt unbuffers the writer at t unless the returned stop func is called first
+func (s *streamWriter) flushAt(t time.Time) func() {
+	fire, stopTimer := newTimer(time.Until(t))
+	done := make(chan struct{})
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+		select {
+		case <-fire:
+			s.unbuffer()
+		case <-done:
+		}
+	}()
+
+	return func() {
+		stopTimer()
+		close(done)
+		<-exited
+	}
+}
+
+// flushEvery flushes on every tick of interval until the returned stop func is called
+func (s *streamWriter) flushEvery(interval time.Duration) func() {
+	tick, stopTicker := newTicker(interval)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+		for {
+			select {
+			case <-tick:
+				s.Flush()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		stopTicker()
+		close(done)
+		<-exited
+	}
+}
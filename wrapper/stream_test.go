@@ -0,0 +1,344 @@
+package wrapper
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_ExecuteStream(t *testing.T) {
+	next := make(chan struct{})
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("first line")
+			// Wait until the first line was read before producing more output
+			<-next
+			fmt.Println("second line")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd)
+	reader, errc := wrapper.ExecuteStream([]string{})
+
+	scanner := bufio.NewScanner(reader)
+
+	if !scanner.Scan() {
+		t.Fatalf("Expected first line, got: %v", scanner.Err())
+	}
+	if scanner.Text() != "first line" {
+		t.Errorf("Expected 'first line', got: %s", scanner.Text())
+	}
+
+	close(next)
+
+	if !scanner.Scan() {
+		t.Fatalf("Expected second line, got: %v", scanner.Err())
+	}
+	if scanner.Text() != "second line" {
+		t.Errorf("Expected 'second line', got: %s", scanner.Text())
+	}
+
+	if scanner.Scan() {
+		t.Errorf("Expected EOF, got: %s", scanner.Text())
+	}
+
+	if err := <-errc; err != nil {
+		t.Errorf("Expected nil error, got: %v", err)
+	}
+}
+
+func TestCobraWrapper_ExecuteStreamError(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Println("before error")
+			return fmt.Errorf("command failed")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd)
+	reader, errc := wrapper.ExecuteStream([]string{})
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read stream: %v", err)
+	}
+
+	if string(data) == "" {
+		t.Error("Expected streamed output before error")
+	}
+
+	err = <-errc
+	if err == nil || err.Error() != "command failed" {
+		t.Errorf("Expected 'command failed', got: %v", err)
+	}
+}
+
+func TestCobraWrapper_ExecuteStreamReaderClosedEarly(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			for i := 0; i < 1000; i++ {
+				fmt.Printf("line %d\n", i)
+			}
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd)
+	reader, errc := wrapper.ExecuteStream([]string{})
+
+	_ = reader.Close()
+
+	if err := <-errc; err != nil {
+		t.Errorf("Expected nil error, got: %v", err)
+	}
+}
+
+// recordingSink records each write it receives as a separate chunk
+type recordingSink struct {
+	mu     sync.Mutex
+	chunks []string
+	wrote  chan struct{}
+}
+
+func newRecordingSink() *recordingSink {
+	return &recordingSink{wrote: make(chan struct{}, 100)}
+}
+
+func (r *recordingSink) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	r.chunks = append(r.chunks, string(p))
+	r.mu.Unlock()
+	r.wrote <- struct{}{}
+	return len(p), nil
+}
+
+func (r *recordingSink) Chunks() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.chunks...)
+}
+
+func TestStreamWriter_FlushSize(t *testing.T) {
+	sink := newRecordingSink()
+	sw := &streamWriter{dst: sink, buffered: true, flushSize: 10}
+
+	_, _ = sw.Write([]byte("abcd"))
+	_, _ = sw.Write([]byte("efgh"))
+
+	if chunks := sink.Chunks(); len(chunks) != 0 {
+		t.Fatalf("Expected no flush below the size threshold, got: %q", chunks)
+	}
+
+	_, _ = sw.Write([]byte("ijkl"))
+
+	if chunks := sink.Chunks(); len(chunks) != 1 || chunks[0] != "abcdefghijkl" {
+		t.Fatalf("Expected a single flush at the threshold, got: %q", chunks)
+	}
+
+	_, _ = sw.Write([]byte("tail"))
+	sw.Flush()
+
+	if chunks := sink.Chunks(); len(chunks) != 2 || chunks[1] != "tail" {
+		t.Errorf("Expected final flush of the remainder, got: %q", chunks)
+	}
+}
+
+func TestStreamWriter_FlushInterval(t *testing.T) {
+	ticks := make(chan time.Time)
+	original := newTicker
+	newTicker = func(d time.Duration) (<-chan time.Time, func()) {
+		return ticks, func() {}
+	}
+	defer func() {
+		newTicker = original
+	}()
+
+	sink := newRecordingSink()
+	sw := &streamWriter{dst: sink, buffered: true}
+	stop := sw.flushEvery(time.Second)
+	defer stop()
+
+	_, _ = sw.Write([]byte("first "))
+	_, _ = sw.Write([]byte("batch"))
+
+	if chunks := sink.Chunks(); len(chunks) != 0 {
+		t.Fatalf("Expected no flush before a tick, got: %q", chunks)
+	}
+
+	ticks <- time.Now()
+	<-sink.wrote
+
+	_, _ = sw.Write([]byte("second"))
+	ticks <- time.Now()
+	<-sink.wrote
+
+	chunks := sink.Chunks()
+	if len(chunks) != 2 || chunks[0] != "first batch" || chunks[1] != "second" {
+		t.Errorf("Expected one flush per tick, got: %q", chunks)
+	}
+}
+
+func TestStreamWriter_Unbuffered(t *testing.T) {
+	sink := newRecordingSink()
+	sw := &streamWriter{dst: sink}
+
+	_, _ = sw.Write([]byte("a"))
+	_, _ = sw.Write([]byte("b"))
+
+	if chunks := sink.Chunks(); len(chunks) != 2 {
+		t.Errorf("Expected every write forwarded immediately, got: %q", chunks)
+	}
+}
+
+func TestCobraWrapper_ExecuteStreamWithFlushOptions(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			for i := 0; i < 50; i++ {
+				fmt.Printf("line %d\n", i)
+			}
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithFlushSize(64), WithFlushInterval(10*time.Millisecond))
+	reader, errc := wrapper.ExecuteStream([]string{})
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read stream: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	for i := 0; i < 50; i++ {
+		if !strings.Contains(string(data), fmt.Sprintf("line %d\n", i)) {
+			t.Fatalf("Missing line %d in streamed output: %q", i, data)
+		}
+	}
+}
+
+// fakeTimer replaces newTimer for the duration of a test, returning the channel
+// firing the timer and the durations timers were created with
+func fakeTimer(t *testing.T) (chan time.Time, *[]time.Duration) {
+	fire := make(chan time.Time, 1)
+	var durations []time.Duration
+
+	original := newTimer
+	newTimer = func(d time.Duration) (<-chan time.Time, func()) {
+		durations = append(durations, d)
+		return fire, func() {}
+	}
+	t.Cleanup(func() {
+		newTimer = original
+	})
+
+	return fire, &durations
+}
+
+func TestStreamWriter_FlushAt(t *testing.T) {
+	fire, _ := fakeTimer(t)
+
+	sink := newRecordingSink()
+	sw := &streamWriter{dst: sink, buffered: true}
+	stop := sw.flushAt(time.Now().Add(time.Minute))
+	defer stop()
+
+	_, _ = sw.Write([]byte("buffered tail"))
+
+	if chunks := sink.Chunks(); len(chunks) != 0 {
+		t.Fatalf("Expected no flush before the deadline, got: %q", chunks)
+	}
+
+	fire <- time.Now()
+	<-sink.wrote
+
+	// writes after the deadline flush go straight through
+	_, _ = sw.Write([]byte("late"))
+
+	chunks := sink.Chunks()
+	if len(chunks) != 2 || chunks[0] != "buffered tail" || chunks[1] != "late" {
+		t.Errorf("Expected the final flush then unbuffered writes, got: %q", chunks)
+	}
+}
+
+func TestCobraWrapper_ExecuteStreamDeadlineFlush(t *testing.T) {
+	fire, durations := fakeTimer(t)
+
+	release := make(chan struct{})
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Print("tail before the deadline")
+			<-release
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	wrapper := NewCobraLambdaCLI(ctx, cmd, WithoutMirror(), WithFlushInterval(time.Hour), WithDeadlineMargin(time.Minute))
+	reader, errc := wrapper.ExecuteStream([]string{})
+
+	// the output is buffered until the deadline flush, which is read while the
+	// command is still running
+	fire <- time.Now()
+
+	buf := make([]byte, 64)
+	n, err := reader.Read(buf)
+	if err != nil {
+		t.Fatalf("Failed to read stream: %v", err)
+	}
+	if string(buf[:n]) != "tail before the deadline" {
+		t.Errorf("Expected the buffered output to be flushed, got: %q", buf[:n])
+	}
+
+	close(release)
+	_, _ = io.ReadAll(reader)
+	if err := <-errc; err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	if len(*durations) != 1 || (*durations)[0] > time.Hour-time.Minute {
+		t.Errorf("Expected the flush to be scheduled a margin before the deadline, got: %v", *durations)
+	}
+}
+
+func TestCobraWrapper_ExecuteStreamDeadlineFlushFromCommandContext(t *testing.T) {
+	_, durations := fakeTimer(t)
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Print("done")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror(), WithFlushInterval(time.Hour), WithDeadlineMargin(time.Minute))
+
+	// the command runs under the context set on it after the wrapper was built
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	cmd.SetContext(ctx)
+
+	reader, errc := wrapper.ExecuteStream([]string{})
+	if out, _ := io.ReadAll(reader); string(out) != "done" {
+		t.Errorf("Got: %q", out)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	if len(*durations) != 1 || (*durations)[0] > time.Hour-time.Minute {
+		t.Errorf("Expected the flush to be scheduled from the command's deadline, got: %v", *durations)
+	}
+}
@@ -0,0 +1,79 @@
+package wrapper
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// commandArgs assembles the cobra arguments for event. Events without Path or
+// Flags run with Args as given, otherwise the invocation is Path followed by
+// Flags, sorted by name, and then Args. Path must name an existing command.
+// Format, when set, is added as the WithFormatFlag flag
+func (w *CobraLambda) commandArgs(event *CobraLambdaEvent) ([]string, error) {
+	if len(event.Path) == 0 && len(event.Flags) == 0 {
+		return w.applyFormat(event.Args, event.Format)
+	}
+
+	if len(event.Path) > 0 {
+		target, rest, err := w.cmd.Find(event.Path)
+		expected := strings.TrimSpace(w.cmd.CommandPath() + " " + strings.Join(event.Path, " "))
+		if err != nil || len(rest) > 0 || target.CommandPath() != expected {
+			return nil, fmt.Errorf("wrapper: path %q does not resolve to a command", strings.Join(event.Path, " "))
+		}
+	}
+
+	args := append([]string{}, event.Path...)
+
+	names := make([]string, 0, len(event.Flags))
+	for name := range event.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		flagArgs, err := formatFlag(name, event.Flags[name])
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, flagArgs...)
+	}
+
+	return w.applyFormat(append(args, event.Args...), event.Format)
+}
+
+// formatFlag renders a decoded JSON flag value as command line arguments. true
+// becomes --name, false --name=false, numbers and strings --name=value so values
+// starting with "-" are not taken for flags, and lists repeat the flag once per
+// element
+func formatFlag(name string, value any) ([]string, error) {
+	flag := "--" + name
+	if len(name) == 1 {
+		flag = "-" + name
+	}
+
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return []string{flag}, nil
+		}
+		return []string{flag + "=false"}, nil
+	case string:
+		return []string{flag + "=" + v}, nil
+	case float64:
+		return []string{flag + "=" + strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	case []any:
+		args := []string{}
+		for _, element := range v {
+			elementArgs, err := formatFlag(name, element)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, elementArgs...)
+		}
+		return args, nil
+	default:
+		return nil, fmt.Errorf("wrapper: unsupported value %v for flag %q", value, name)
+	}
+}
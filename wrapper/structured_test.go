@@ -0,0 +1,156 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestNewCobrLambdaHandler_StructuredEvent(t *testing.T) {
+	var received []string
+	var dryRun bool
+	var steps int
+	var tags []string
+	migrate := &cobra.Command{
+		Use: "migrate",
+		Run: func(cmd *cobra.Command, args []string) {
+			received = append([]string{}, args...)
+			cmd.Printf("dry-run=%v steps=%d tags=%v\n", dryRun, steps, tags)
+		},
+	}
+	migrate.Flags().BoolVar(&dryRun, "dry-run", true, "Only print the plan")
+	migrate.Flags().IntVar(&steps, "steps", 0, "Number of steps")
+	migrate.Flags().StringSliceVar(&tags, "tag", nil, "Tags")
+
+	db := &cobra.Command{Use: "db"}
+	db.AddCommand(migrate)
+	root := &cobra.Command{Use: "app"}
+	root.AddCommand(db)
+
+	handler := NewCobrLambdaHandler(root, WithEchoArgs())
+
+	eventJSON := json.RawMessage(`{
+		"path": ["db", "migrate"],
+		"flags": {"dry-run": false, "steps": 3, "tag": ["a", "b"]},
+		"args": ["x"]
+	}`)
+
+	result, err := handler(context.Background(), eventJSON)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	output := result.(*CobraLambdaOutput)
+	if !strings.Contains(output.Stdout, "dry-run=false steps=3 tags=[a b]") {
+		t.Errorf("Expected flags to be applied, got: %s", output.Stdout)
+	}
+	if strings.Join(received, ",") != "x" {
+		t.Errorf("Expected positional args [x], got: %v", received)
+	}
+
+	expected := "db migrate --dry-run=false --steps=3 --tag=a --tag=b x"
+	if strings.Join(output.Args, " ") != expected {
+		t.Errorf("Expected invocation %q, got: %q", expected, strings.Join(output.Args, " "))
+	}
+}
+
+func TestNewCobrLambdaHandler_StructuredEventBooleanTrue(t *testing.T) {
+	var dryRun bool
+	migrate := &cobra.Command{
+		Use: "migrate",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Printf("dry-run=%v\n", dryRun)
+		},
+	}
+	migrate.Flags().BoolVar(&dryRun, "dry-run", false, "Only print the plan")
+
+	db := &cobra.Command{Use: "db"}
+	db.AddCommand(migrate)
+	cmd := &cobra.Command{Use: "app"}
+	cmd.AddCommand(db)
+
+	output, err := NewTypedHandler(cmd)(context.Background(), CobraLambdaEvent{
+		Path:  []string{"db", "migrate"},
+		Flags: map[string]any{"dry-run": true},
+	})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "dry-run=true") {
+		t.Errorf("Got: %s", output.Stdout)
+	}
+}
+
+func TestNewCobrLambdaHandler_StructuredEventInvalidPath(t *testing.T) {
+	db := &cobra.Command{Use: "db"}
+	db.AddCommand(&cobra.Command{
+		Use: "migrate",
+		Run: func(cmd *cobra.Command, args []string) {},
+	})
+	cmd := &cobra.Command{Use: "app"}
+	cmd.AddCommand(db)
+
+	for _, path := range [][]string{{"db", "rollback"}, {"nope"}} {
+		_, err := NewTypedHandler(cmd)(context.Background(), CobraLambdaEvent{Path: path})
+		if err == nil || !strings.Contains(err.Error(), "does not resolve to a command") {
+			t.Errorf("Expected unresolved path error for %v, got: %v", path, err)
+		}
+	}
+}
+
+func TestNewCobrLambdaHandler_StructuredFlagDashValue(t *testing.T) {
+	var name string
+	cmd := &cobra.Command{
+		Use: "greet",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Printf("name=%s args=%v", name, args)
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "", "")
+
+	output, err := NewTypedHandler(cmd, WithoutMirror())(context.Background(), CobraLambdaEvent{
+		Flags: map[string]any{"name": "--help"},
+	})
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if output.Stdout != "name=--help args=[]" {
+		t.Errorf("Expected the value to reach the flag, got: %s", output.Stdout)
+	}
+}
+
+func TestFormatFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{"verbose", true, "--verbose"},
+		{"verbose", false, "--verbose=false"},
+		{"steps", float64(3), "--steps=3"},
+		{"ratio", 0.5, "--ratio=0.5"},
+		{"offset", float64(-2), "--offset=-2"},
+		{"name", "two words", "--name=two words"},
+		{"name", "-x", "--name=-x"},
+		{"v", true, "-v"},
+		{"n", "value", "-n=value"},
+	}
+
+	for _, tt := range tests {
+		args, err := formatFlag(tt.name, tt.value)
+		if err != nil {
+			t.Fatalf("formatFlag failed: %v", err)
+		}
+		if strings.Join(args, " ") != tt.expected {
+			t.Errorf("Expected %q, got: %q", tt.expected, args)
+		}
+	}
+
+	if _, err := formatFlag("config", map[string]any{"a": 1}); err == nil {
+		t.Error("Expected error for object value")
+	}
+}
@@ -0,0 +1,157 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// TelemetryTypeInvocation is the type of the event emitted for every invocation
+const TelemetryTypeInvocation = "function.cobraInvocation"
+
+// Invocation statuses reported in TelemetryRecord.Status
+const (
+	TelemetryStatusSuccess = "success"
+	TelemetryStatusFailure = "failure"
+	TelemetryStatusTimeout = "timeout"
+)
+
+// TelemetryEvent is an invocation timing record in the envelope used by the
+// Lambda Telemetry API, {"time": ..., "type": ..., "record": {...}}
+type TelemetryEvent struct {
+	Time   time.Time       `json:"time"`
+	Type   string          `json:"type"`
+	Record TelemetryRecord `json:"record"`
+}
+
+type TelemetryRecord struct {
+	RequestID string           `json:"requestId"`
+	Status    string           `json:"status"`
+	ExitCode  int              `json:"exitCode"`
+	ErrorType string           `json:"errorType,omitempty"`
+	Metrics   TelemetryMetrics `json:"metrics"`
+}
+
+type TelemetryMetrics struct {
+	// DurationMs is the handler's time for the invocation, including capture setup
+	DurationMs float64 `json:"durationMs"`
+	// CommandDurationMs is the time the command itself ran
+	CommandDurationMs int64 `json:"commandDurationMs"`
+}
+
+// TelemetryEmitter sends telemetry events to a sink
+type TelemetryEmitter interface {
+	Emit(ctx context.Context, event TelemetryEvent) error
+}
+
+// WithTelemetry emits a TelemetryEvent with the timings and outcome of every
+// invocation to emitter once it has finished. Emit failures are reported on
+// stderr and do not fail the invocation
+func WithTelemetry(emitter TelemetryEmitter) Option {
+	return WithPostExecHook(func(ctx context.Context, event *CobraLambdaEvent, output *CobraLambdaOutput, err error, elapsed time.Duration) {
+		if emitErr := emitter.Emit(ctx, newTelemetryEvent(ctx, output, err, elapsed)); emitErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "wrapper: telemetry: %v\n", emitErr)
+		}
+	})
+}
+
+func newTelemetryEvent(ctx context.Context, output *CobraLambdaOutput, err error, elapsed time.Duration) TelemetryEvent {
+	record := TelemetryRecord{
+		RequestID: RequestID(ctx),
+		Status:    TelemetryStatusSuccess,
+		Metrics: TelemetryMetrics{
+			DurationMs: float64(elapsed.Microseconds()) / 1000,
+		},
+	}
+
+	if output != nil {
+		record.ExitCode = output.ExitCode
+		record.Metrics.CommandDurationMs = output.DurationMs
+	}
+
+	switch {
+	case output != nil && output.TimedOut:
+		record.Status = TelemetryStatusTimeout
+	case err != nil:
+		record.Status = TelemetryStatusFailure
+		record.ErrorType = fmt.Sprintf("%T", err)
+		if record.ExitCode == 0 {
+			record.ExitCode = 1
+		}
+	}
+
+	return TelemetryEvent{
+		Time:   time.Now().UTC(),
+		Type:   TelemetryTypeInvocation,
+		Record: record,
+	}
+}
+
+// JSONLinesEmitter writes each event as a line of JSON, e.g. to os.Stdout where
+// the Lambda runtime forwards it to CloudWatch
+type JSONLinesEmitter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewJSONLinesEmitter(w io.Writer) *JSONLinesEmitter {
+	return &JSONLinesEmitter{w: w}
+}
+
+func (e *JSONLinesEmitter) Emit(ctx context.Context, event TelemetryEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, err = e.w.Write(append(line, '\n'))
+	return err
+}
+
+// HTTPEmitter POSTs each event as a JSON array of one, the batch format the
+// Telemetry API delivers to subscribers, to URL
+type HTTPEmitter struct {
+	URL string
+	// Client sends the requests, http.DefaultClient when nil
+	Client *http.Client
+}
+
+func (e *HTTPEmitter) Emit(ctx context.Context, event TelemetryEvent) error {
+	body, err := json.Marshal([]TelemetryEvent{event})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("telemetry endpoint returned %s", response.Status)
+	}
+
+	return nil
+}
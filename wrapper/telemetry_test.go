@@ -0,0 +1,95 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/spf13/cobra"
+)
+
+func TestNewTypedHandler_Telemetry(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Print("ok")
+		},
+	}
+
+	buffer := &bytes.Buffer{}
+	handler := NewTypedHandler(cmd, WithoutMirror(), WithTelemetry(NewJSONLinesEmitter(buffer)))
+
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "req-1"})
+	if _, err := handler(ctx, CobraLambdaEvent{Args: []string{}}); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", buffer.String(), err)
+	}
+
+	if record["type"] != TelemetryTypeInvocation {
+		t.Errorf("Unexpected type: %v", record["type"])
+	}
+	if _, ok := record["time"].(string); !ok {
+		t.Errorf("Expected a time, got: %v", record["time"])
+	}
+
+	body, ok := record["record"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected a record object, got: %v", record["record"])
+	}
+	if body["requestId"] != "req-1" || body["status"] != TelemetryStatusSuccess || body["exitCode"] != float64(0) {
+		t.Errorf("Unexpected record: %v", body)
+	}
+	if _, ok := body["errorType"]; ok {
+		t.Errorf("Expected no errorType on success, got: %v", body["errorType"])
+	}
+
+	metrics, ok := body["metrics"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected metrics, got: %v", body["metrics"])
+	}
+	if duration, ok := metrics["durationMs"].(float64); !ok || duration < 0 {
+		t.Errorf("Expected a non-negative durationMs, got: %v", metrics["durationMs"])
+	}
+	if _, ok := metrics["commandDurationMs"].(float64); !ok {
+		t.Errorf("Expected commandDurationMs, got: %v", metrics["commandDurationMs"])
+	}
+}
+
+func TestNewTypedHandler_TelemetryFailure(t *testing.T) {
+	var events []TelemetryEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+			t.Errorf("Failed to decode telemetry batch: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return errors.New("command failed")
+		},
+	}
+
+	handler := NewTypedHandler(cmd, WithoutMirror(), WithTelemetry(&HTTPEmitter{URL: server.URL}))
+
+	if _, err := handler(context.Background(), CobraLambdaEvent{Args: []string{"fail"}}); err == nil {
+		t.Fatal("Expected error but got none")
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Expected one event, got: %d", len(events))
+	}
+	if record := events[0].Record; record.Status != TelemetryStatusFailure || record.ExitCode != 1 || record.ErrorType == "" {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+}
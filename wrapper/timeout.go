@@ -0,0 +1,92 @@
+package wrapper
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// TimeoutAnnotation is the command annotation holding the maximum time the
+// command may run for, in time.ParseDuration format such as "30s". Execute
+// cancels the command context once it elapses and returns the output captured
+// so far with TimedOut set
+const TimeoutAnnotation = "timeout"
+
+// commandTimeout returns the timeout annotated on the command args resolve to
+// along with its path. The timeout is 0 when the command has no annotation
+func commandTimeout(root *cobra.Command, args []string) (time.Duration, string, error) {
+	target, _, err := root.Find(args)
+	if err != nil || target == nil {
+		return 0, "", nil
+	}
+
+	value, ok := target.Annotations[TimeoutAnnotation]
+	if !ok {
+		return 0, "", nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, "", fmt.Errorf("wrapper: invalid %s annotation %q on command %q", TimeoutAnnotation, value, target.CommandPath())
+	}
+
+	return timeout, target.CommandPath(), nil
+}
+
+// WithTimeoutFlag lets callers set the command timeout with --name, e.g.
+// WithTimeoutFlag("cl-timeout") and args of ["report", "--cl-timeout", "30s"].
+// The flag takes a time.ParseDuration value and is removed from args before the
+// command sees them, so it is not defined on the command tree. Arguments after
+// "--" are left alone. When the command also has a TimeoutAnnotation the
+// shorter timeout applies
+func WithTimeoutFlag(name string) Option {
+	return func(w *CobraLambda) {
+		w.timeoutFlag = name
+	}
+}
+
+// extractTimeoutFlag removes the timeout flag from args, returning the
+// remaining args and the timeout, 0 when the flag is not set
+func (w *CobraLambda) extractTimeoutFlag(args []string) ([]string, time.Duration, error) {
+	if w.timeoutFlag == "" {
+		return args, 0, nil
+	}
+
+	flag := "--" + w.timeoutFlag
+	remaining := make([]string, 0, len(args))
+	var value string
+	found := false
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "--":
+			remaining = append(remaining, args[i:]...)
+			i = len(args)
+		case arg == flag:
+			if i+1 >= len(args) {
+				return nil, 0, fmt.Errorf("wrapper: flag %s needs a duration", flag)
+			}
+			value, found = args[i+1], true
+			i++
+		case strings.HasPrefix(arg, flag+"="):
+			value, found = strings.TrimPrefix(arg, flag+"="), true
+		default:
+			remaining = append(remaining, arg)
+		}
+	}
+
+	if !found {
+		return args, 0, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return nil, 0, fmt.Errorf("wrapper: invalid %s value %q, expected a positive duration such as 30s", flag, value)
+	}
+
+	return remaining, timeout, nil
+}
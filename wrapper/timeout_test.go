@@ -0,0 +1,204 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_CommandTimeoutAnnotation(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	root.AddCommand(&cobra.Command{
+		Use:         "limited",
+		Annotations: map[string]string{TimeoutAnnotation: "50ms"},
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("started")
+			select {
+			case <-cmd.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		},
+	})
+	root.AddCommand(&cobra.Command{
+		Use: "quick",
+		Run: func(cmd *cobra.Command, args []string) {
+			_, hasDeadline := cmd.Context().Deadline()
+			fmt.Printf("deadline: %v\n", hasDeadline)
+		},
+	})
+
+	wrapper := NewCobraLambdaCLI(context.Background(), root)
+
+	start := time.Now()
+	output, err := wrapper.Execute([]string{"limited"})
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "root limited") {
+		t.Errorf("Expected error to name the command, got: %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("Expected command to be stopped after its timeout, took %s", elapsed)
+	}
+	if !output.TimedOut {
+		t.Error("Expected TimedOut to be set")
+	}
+	if !strings.Contains(output.Stdout, "started") {
+		t.Errorf("Expected partial output, got: %s", output.Stdout)
+	}
+
+	// the timed out run context must not be left on the command
+	output, err = wrapper.Execute([]string{"quick"})
+	if err != nil {
+		t.Fatalf("Expected the next execution to succeed, got: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "deadline: false") {
+		t.Errorf("Expected the next execution to run without a deadline, got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_CommandWithoutTimeoutAnnotation(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	root.AddCommand(&cobra.Command{
+		Use: "quick",
+		Run: func(cmd *cobra.Command, args []string) {
+			_, hasDeadline := cmd.Context().Deadline()
+			fmt.Printf("deadline: %v\n", hasDeadline)
+		},
+	})
+
+	wrapper := NewCobraLambdaCLI(context.Background(), root)
+
+	output, err := wrapper.Execute([]string{"quick"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.TimedOut {
+		t.Error("Expected TimedOut to be unset")
+	}
+	if !strings.Contains(output.Stdout, "deadline: false") {
+		t.Errorf("Expected no deadline without the annotation, got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_InvalidTimeoutAnnotation(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	root.AddCommand(&cobra.Command{
+		Use:         "broken",
+		Annotations: map[string]string{TimeoutAnnotation: "soon"},
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("started")
+		},
+	})
+
+	wrapper := NewCobraLambdaCLI(context.Background(), root)
+
+	output, err := wrapper.Execute([]string{"broken"})
+	if err == nil || !strings.Contains(err.Error(), `invalid timeout annotation "soon"`) {
+		t.Fatalf("Expected invalid annotation error, got: %v", err)
+	}
+	if strings.Contains(output.Stdout, "started") {
+		t.Errorf("Expected command not to run, got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_TimeoutFlag(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	root.AddCommand(&cobra.Command{
+		Use:         "limited",
+		Annotations: map[string]string{TimeoutAnnotation: "50ms"},
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("started")
+			select {
+			case <-cmd.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		},
+	})
+	root.AddCommand(&cobra.Command{
+		Use: "quick",
+		Run: func(cmd *cobra.Command, args []string) {
+			_, hasDeadline := cmd.Context().Deadline()
+			fmt.Printf("deadline: %v\n", hasDeadline)
+		},
+	})
+	root.AddCommand(&cobra.Command{
+		Use: "slow",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("args=%v\n", args)
+			select {
+			case <-cmd.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		},
+	})
+
+	wrapper := NewCobraLambdaCLI(context.Background(), root, WithoutMirror(), WithTimeoutFlag("cl-timeout"))
+
+	start := time.Now()
+	output, err := wrapper.Execute([]string{"slow", "--cl-timeout", "50ms", "a", "--", "--cl-timeout=1s"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the flag timeout to elapse, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the command to be abandoned promptly, took %v", elapsed)
+	}
+	if !output.TimedOut {
+		t.Error("Expected TimedOut to be set")
+	}
+	if !strings.Contains(output.Stdout, "args=[a --cl-timeout=1s]") {
+		t.Errorf("Expected the flag to be stripped before --, got: %s", output.Stdout)
+	}
+
+	// the flag only applies to the execution it was given to
+	output, err = wrapper.Execute([]string{"quick"})
+	if err != nil {
+		t.Fatalf("Expected the next execution to succeed, got: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "deadline: false") {
+		t.Errorf("Expected no deadline without the flag, got: %s", output.Stdout)
+	}
+
+	output, err = wrapper.Execute([]string{"quick", "--cl-timeout=10s"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "deadline: true") {
+		t.Errorf("Expected the command context to have a deadline, got: %s", output.Stdout)
+	}
+
+	// the annotation's shorter 50ms timeout wins over the flag
+	if _, err := wrapper.Execute([]string{"limited", "--cl-timeout=10s"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the annotated timeout to apply, got: %v", err)
+	}
+}
+
+func TestCobraWrapper_TimeoutFlagInvalid(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	root.AddCommand(&cobra.Command{
+		Use: "quick",
+		Run: func(cmd *cobra.Command, args []string) {
+			_, hasDeadline := cmd.Context().Deadline()
+			fmt.Printf("deadline: %v\n", hasDeadline)
+		},
+	})
+
+	wrapper := NewCobraLambdaCLI(context.Background(), root, WithoutMirror(), WithTimeoutFlag("cl-timeout"))
+
+	for _, args := range [][]string{
+		{"quick", "--cl-timeout", "soon"},
+		{"quick", "--cl-timeout=-1s"},
+		{"quick", "--cl-timeout"},
+	} {
+		if _, err := wrapper.Execute(args); err == nil || !strings.Contains(err.Error(), "--cl-timeout") {
+			t.Errorf("%v: expected an error naming the flag, got %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// WithoutGeneratedCommands stops cobra from adding its completion command and
+// hides the generated help command so neither appears in captured help output.
+// Help for a command is still available through the --help flag
+func WithoutGeneratedCommands() Option {
+	return func(w *CobraLambda) {
+		w.withoutGeneratedCommands = true
+	}
+}
+
+// WithFlagParsingDisabled turns off cobra flag parsing for every command in the
+// tree so all event args, including ones that look like flags, reach Run untouched
+func WithFlagParsingDisabled() Option {
+	return func(w *CobraLambda) {
+		w.flagParsingDisabled = true
+	}
+}
+
+// disableFlagParsing sets DisableFlagParsing on cmd and all of its subcommands
+func disableFlagParsing(cmd *cobra.Command) {
+	cmd.DisableFlagParsing = true
+
+	for _, sub := range cmd.Commands() {
+		disableFlagParsing(sub)
+	}
+}
+
+// WithCleanErrors sets SilenceErrors and SilenceUsage on every command in the
+// tree so a failing command's error is only returned from Execute and not also
+// printed with the usage into the captured output
+func WithCleanErrors() Option {
+	return func(w *CobraLambda) {
+		w.cleanErrors = true
+	}
+}
+
+// silenceErrors sets SilenceErrors and SilenceUsage on cmd and all of its subcommands
+func silenceErrors(cmd *cobra.Command) {
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+
+	for _, sub := range cmd.Commands() {
+		silenceErrors(sub)
+	}
+}
+
+// WithProgramName sets the program name shown in captured help and usage output.
+// On Lambda there is no meaningful args[0] so this lets usage text match the
+// name of the client tool callers actually use. os.Args[0] is set to name while
+// the command runs, for tools deriving their name from it, and restored after
+func WithProgramName(name string) Option {
+	return func(w *CobraLambda) {
+		w.programName = name
+	}
+}
+
+// setProgramName makes cobra display name in place of the root command's name
+func setProgramName(cmd *cobra.Command, name string) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[cobra.CommandDisplayNameAnnotation] = name
+}
+
+// removeGeneratedCommands disables cobra's default completion command, removing
+// one already added by a previous execution, and replaces the default help
+// command with a hidden equivalent
+func removeGeneratedCommands(cmd *cobra.Command) {
+	removeCompletionCommand(cmd)
+
+	cmd.SetHelpCommand(&cobra.Command{
+		Use:    "help [command]",
+		Hidden: true,
+		Run: func(c *cobra.Command, args []string) {
+			target, _, err := c.Root().Find(args)
+			if target == nil || err != nil {
+				target = c.Root()
+			}
+			_ = target.Help()
+		},
+	})
+}
+
+// removeCompletionCommand stops cobra from adding its completion command and
+// removes it when a previous execution already added it
+func removeCompletionCommand(cmd *cobra.Command) {
+	cmd.CompletionOptions.DisableDefaultCmd = true
+
+	for _, sub := range cmd.Commands() {
+		if sub.Name() == "completion" && sub.Short == generatedCompletionShort {
+			cmd.RemoveCommand(sub)
+		}
+	}
+}
+
+// ErrCompletionDisabled is returned when an invocation asks for shell completion
+// and WithCompletionDisabled is set
+var ErrCompletionDisabled = errors.New("wrapper: shell completion is not available over Lambda")
+
+// WithCompletionDisabled removes cobra's generated completion command and fails
+// invocations of it, or of cobra's hidden __complete commands, with
+// ErrCompletionDisabled instead of returning completion scripts that cannot be
+// used remotely. The command must be the first argument. A completion command
+// defined by the application is left alone
+func WithCompletionDisabled() Option {
+	return func(w *CobraLambda) {
+		w.completionDisabled = true
+	}
+}
+
+// checkCompletion fails when completion is disabled and args invoke it
+func (w *CobraLambda) checkCompletion(args []string) error {
+	if !w.completionDisabled || len(args) == 0 {
+		return nil
+	}
+
+	switch args[0] {
+	case cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+	case "completion":
+		for _, sub := range w.cmd.Commands() {
+			if sub.Name() == "completion" {
+				return nil
+			}
+		}
+	default:
+		return nil
+	}
+
+	return fmt.Errorf("%w: %q", ErrCompletionDisabled, args[0])
+}
+
+// generatedCompletionShort is the Short description cobra gives the completion
+// command it generates, used to avoid removing a user defined completion command
+const generatedCompletionShort = "Generate the autocompletion script for the specified shell"
+
+// ErrCommandNotRunnable is returned when the invoked command has neither Run nor
+// RunE and no subcommands, where cobra would print help and succeed with output
+// that hides the misconfigured tree
+var ErrCommandNotRunnable = errors.New("wrapper: command is not runnable")
+
+// checkRunnable fails when args resolve to a command without Run or RunE that
+// has no subcommands either. Asking such a command for help with --help or -h
+// is allowed, as are args cobra cannot resolve, which it reports itself
+func checkRunnable(root *cobra.Command, args []string) error {
+	target, rest, err := root.Find(args)
+	if err != nil || target == nil {
+		return nil
+	}
+
+	if target.Runnable() || target.HasSubCommands() || helpRequested(rest) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %q has no Run or RunE and no subcommands", ErrCommandNotRunnable, target.CommandPath())
+}
+
+// helpRequested reports whether args ask for help before any "--"
+func helpRequested(args []string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			return false
+		case "--help", "-h", "--help=true", "-h=true":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,296 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func hasCommand(cmd *cobra.Command, name string) bool {
+	for _, sub := range cmd.Commands() {
+		if sub.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCobraWrapper_GeneratedCommandsByDefault(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "sub",
+		Short: "A subcommand",
+		Run:   func(cmd *cobra.Command, args []string) {},
+	})
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), rootCmd)
+	output, err := wrapper.Execute([]string{"--help"})
+
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !hasCommand(rootCmd, "completion") {
+		t.Error("Expected cobra to add the completion command by default")
+	}
+	if !strings.Contains(output.Stdout, "completion") {
+		t.Errorf("Expected completion in help output. Got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_WithoutGeneratedCommands(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "sub",
+		Short: "A subcommand",
+		Run:   func(cmd *cobra.Command, args []string) {},
+	})
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), rootCmd, WithoutGeneratedCommands())
+	output, err := wrapper.Execute([]string{"--help"})
+
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if hasCommand(rootCmd, "completion") {
+		t.Error("Expected completion command to be absent from the command tree")
+	}
+	if strings.Contains(output.Stdout, "completion") {
+		t.Errorf("Expected no completion in help output. Got: %s", output.Stdout)
+	}
+	if strings.Contains(output.Stdout, "Help about any command") {
+		t.Errorf("Expected no help command in help output. Got: %s", output.Stdout)
+	}
+	if !strings.Contains(output.Stdout, "A subcommand") {
+		t.Errorf("Expected user commands in help output. Got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_WithoutGeneratedCommandsAfterExecution(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "sub",
+		Short: "A subcommand",
+		Run:   func(cmd *cobra.Command, args []string) {},
+	})
+
+	// A previous execution already added the completion command
+	_, err := NewCobraLambdaCLI(context.TODO(), rootCmd).Execute([]string{"sub"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), rootCmd, WithoutGeneratedCommands())
+	if _, err := wrapper.Execute([]string{"sub"}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if hasCommand(rootCmd, "completion") {
+		t.Error("Expected completion command to be removed from the command tree")
+	}
+}
+
+func TestCobraWrapper_WithFlagParsingDisabled(t *testing.T) {
+	var received []string
+	rootCmd := &cobra.Command{
+		Use: "root",
+	}
+	rootCmd.AddCommand(&cobra.Command{
+		Use: "passthrough",
+		Run: func(cmd *cobra.Command, args []string) {
+			received = args
+		},
+	})
+
+	args := []string{"passthrough", "--verbose", "-x", "value", "--help", "--", "rest"}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), rootCmd, WithFlagParsingDisabled())
+	if _, err := wrapper.Execute(args); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	expected := args[1:]
+	if strings.Join(received, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected args %v, got: %v", expected, received)
+	}
+}
+
+func TestCobraWrapper_WithProgramName(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "sub",
+		Short: "A subcommand",
+		Run:   func(cmd *cobra.Command, args []string) {},
+	})
+	rootCmd.Use = "bootstrap"
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), rootCmd, WithProgramName("clctl --name my-func"))
+
+	output, err := wrapper.Execute([]string{"--help"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "clctl --name my-func [command]") {
+		t.Errorf("Expected program name in usage. Got: %s", output.Stdout)
+	}
+	if strings.Contains(output.Stdout, "bootstrap") {
+		t.Errorf("Expected original name to be replaced. Got: %s", output.Stdout)
+	}
+
+	output, err = wrapper.Execute([]string{"sub", "--help"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "clctl --name my-func sub") {
+		t.Errorf("Expected program name in subcommand usage. Got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_NotRunnable(t *testing.T) {
+	root := &cobra.Command{Use: "app"}
+	group := &cobra.Command{Use: "db"}
+	runnable := &cobra.Command{Use: "status", Run: func(cmd *cobra.Command, args []string) {}}
+	root.AddCommand(group, runnable)
+
+	tests := []struct {
+		name  string
+		cmd   *cobra.Command
+		args  []string
+		fails bool
+	}{
+		{name: "root without run or subcommands", cmd: &cobra.Command{Use: "empty"}, args: []string{}, fails: true},
+		{name: "group without subcommands", cmd: root, args: []string{"db"}, fails: true},
+		{name: "help on misconfigured command", cmd: &cobra.Command{Use: "empty"}, args: []string{"--help"}},
+		{name: "root with subcommands", cmd: root, args: []string{}},
+		{name: "runnable subcommand", cmd: root, args: []string{"status"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapper := NewCobraLambdaCLI(context.TODO(), tt.cmd, WithoutMirror())
+
+			_, err := wrapper.Execute(tt.args)
+
+			if tt.fails && !errors.Is(err, ErrCommandNotRunnable) {
+				t.Fatalf("Expected ErrCommandNotRunnable, got %v", err)
+			}
+			if !tt.fails && err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestCobraWrapper_WithCompletionDisabled(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "completion bash", args: []string{"completion", "bash"}},
+		{name: "completion", args: []string{"completion"}},
+		{name: "complete request", args: []string{cobra.ShellCompRequestCmd, "sub", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootCmd := &cobra.Command{Use: "root"}
+			rootCmd.AddCommand(&cobra.Command{
+				Use:   "sub",
+				Short: "A subcommand",
+				Run:   func(cmd *cobra.Command, args []string) {},
+			})
+
+			wrapper := NewCobraLambdaCLI(context.TODO(), rootCmd, WithoutMirror(), WithCompletionDisabled())
+
+			_, err := wrapper.Execute(tt.args)
+			if !errors.Is(err, ErrCompletionDisabled) {
+				t.Fatalf("Expected ErrCompletionDisabled, got %v", err)
+			}
+		})
+	}
+
+	rootCmd := &cobra.Command{Use: "root"}
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "sub",
+		Short: "A subcommand",
+		Run:   func(cmd *cobra.Command, args []string) {},
+	})
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), rootCmd, WithoutMirror(), WithCompletionDisabled())
+
+	output, err := wrapper.Execute([]string{"--help"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if strings.Contains(output.Stdout, "completion") {
+		t.Errorf("Expected no completion command in help, got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_WithCompletionDisabledUserCommand(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	root.AddCommand(&cobra.Command{
+		Use:   "sub",
+		Short: "A subcommand",
+		Run:   func(cmd *cobra.Command, args []string) {},
+	})
+	root.AddCommand(&cobra.Command{
+		Use: "completion",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Print("custom completion")
+		},
+	})
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), root, WithoutMirror(), WithCompletionDisabled())
+
+	output, err := wrapper.Execute([]string{"completion"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "custom completion") {
+		t.Errorf("Got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_WithCleanErrors(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	sub := &cobra.Command{
+		Use: "fail",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.Print("partial output")
+			return errFailed
+		},
+	}
+	sub.Flags().Int("count", 0, "a count")
+	rootCmd.AddCommand(sub)
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), rootCmd, WithoutMirror(), WithCleanErrors())
+
+	for _, tt := range []struct {
+		args   []string
+		stdout string
+	}{
+		{args: []string{"fail"}, stdout: "partial output"},
+		{args: []string{"fail", "--count", "abc"}, stdout: ""},
+		{args: []string{"missing"}, stdout: ""},
+	} {
+		output, err := wrapper.Execute(tt.args)
+		if err == nil {
+			t.Errorf("Expected an error for %v", tt.args)
+			continue
+		}
+
+		if output.Stdout != tt.stdout {
+			t.Errorf("Expected only command output for %v, got: %q", tt.args, output.Stdout)
+		}
+		if strings.Contains(output.Stdout, "Error:") || strings.Contains(output.Stdout, "Usage:") {
+			t.Errorf("Expected no error or usage text in output for %v, got: %q", tt.args, output.Stdout)
+		}
+	}
+
+	if !sub.SilenceErrors || !sub.SilenceUsage {
+		t.Error("Expected subcommands to be silenced")
+	}
+}
@@ -0,0 +1,96 @@
+package wrapper
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// usageError marks an error returned by a command's flag parsing or argument
+// validation, as opposed to one returned by the command itself
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *usageError) Unwrap() error {
+	return e.err
+}
+
+// usageMarked holds the commands whose FlagErrorFunc and Args already mark
+// their errors, so handlers building a wrapper per invocation wrap them once
+var usageMarked sync.Map
+
+// markUsageErrors wraps the FlagErrorFunc and Args of cmd and its subcommands
+// so the errors they return are marked as usage errors. A nil Args is left as
+// is, cobra treats it differently from any validator when resolving commands
+func markUsageErrors(cmd *cobra.Command) {
+	if _, loaded := usageMarked.LoadOrStore(cmd, struct{}{}); !loaded {
+		flagErrorFunc := cmd.FlagErrorFunc()
+		cmd.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+			return asUsageError(flagErrorFunc(c, err))
+		})
+
+		if validate := cmd.Args; validate != nil {
+			cmd.Args = func(c *cobra.Command, args []string) error {
+				return asUsageError(validate(c, args))
+			}
+		}
+	}
+
+	for _, sub := range cmd.Commands() {
+		markUsageErrors(sub)
+	}
+}
+
+func asUsageError(err error) error {
+	var usageErr *usageError
+	if err == nil || errors.As(err, &usageErr) {
+		return err
+	}
+	return &usageError{err: err}
+}
+
+// WithUsageOnError sets CobraLambdaOutput.Usage to the usage of the command that
+// was invoked incorrectly, for example with an unknown flag or the wrong number of
+// arguments. Combine with SilenceUsage on the command to keep usage out of Stdout
+func WithUsageOnError() Option {
+	return func(w *CobraLambda) {
+		w.usageOnError = true
+	}
+}
+
+// isUsageError reports whether err was caused by an invalid invocation of root
+// with args. Besides marked errors these are the errors cobra returns itself
+// before running the command, when args do not resolve to a command or miss
+// required flags, which are checked again here
+func isUsageError(root *cobra.Command, args []string, err error) bool {
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		return true
+	}
+
+	target, _, findErr := root.Find(args)
+	if findErr != nil {
+		return true
+	}
+	if target == nil || target.DisableFlagParsing {
+		return false
+	}
+
+	return target.ValidateRequiredFlags() != nil || target.ValidateFlagGroups() != nil
+}
+
+// usageFor returns the usage of the command args resolve to, falling back to
+// the root command when they do not resolve
+func usageFor(root *cobra.Command, args []string) string {
+	target, _, err := root.Find(args)
+	if err != nil || target == nil {
+		target = root
+	}
+	return target.UsageString()
+}
@@ -0,0 +1,153 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCobraWrapper_UsageOnBadFlag(t *testing.T) {
+	root := &cobra.Command{Use: "root", SilenceUsage: true}
+	deploy := &cobra.Command{
+		Use:  "deploy [stack]",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+	deploy.Flags().String("region", "us-east-1", "AWS region")
+	root.AddCommand(deploy)
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), root, WithUsageOnError(), WithoutMirror())
+
+	output, err := wrapper.Execute([]string{"deploy", "--bogus", "prod"})
+	if err == nil || !strings.Contains(err.Error(), "unknown flag: --bogus") {
+		t.Fatalf("Expected unknown flag error, got: %v", err)
+	}
+
+	if !strings.Contains(output.Usage, "root deploy [stack] [flags]") || !strings.Contains(output.Usage, "--region") {
+		t.Errorf("Expected deploy usage, got: %q", output.Usage)
+	}
+	if strings.Contains(output.Stdout, "Usage:") {
+		t.Errorf("Expected usage to be kept out of Stdout, got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_UsageOnBadArgs(t *testing.T) {
+	root := &cobra.Command{Use: "root", SilenceUsage: true}
+	deploy := &cobra.Command{
+		Use:  "deploy [stack]",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+	root.AddCommand(deploy)
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), root, WithUsageOnError(), WithoutMirror())
+
+	output, err := wrapper.Execute([]string{"deploy"})
+	if err == nil {
+		t.Fatal("Expected argument count error, got nil")
+	}
+	if !strings.Contains(output.Usage, "root deploy [stack] [flags]") {
+		t.Errorf("Expected deploy usage, got: %q", output.Usage)
+	}
+}
+
+func TestCobraWrapper_UsageNotSetForCommandErrors(t *testing.T) {
+	root := &cobra.Command{Use: "root", SilenceUsage: true}
+	deploy := &cobra.Command{
+		Use:  "deploy [stack]",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return errors.New("deployment failed")
+		},
+	}
+	root.AddCommand(deploy)
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), root, WithUsageOnError(), WithoutMirror())
+
+	output, err := wrapper.Execute([]string{"deploy", "broken"})
+	if err == nil || err.Error() != "deployment failed" {
+		t.Fatalf("Expected command error, got: %v", err)
+	}
+	if output.Usage != "" {
+		t.Errorf("Expected no usage for a command error, got: %q", output.Usage)
+	}
+
+	// without the option usage is never set
+	output, _ = NewCobraLambdaCLI(context.TODO(), root, WithoutMirror()).Execute([]string{"deploy", "--bogus"})
+	if output.Usage != "" {
+		t.Errorf("Expected no usage without WithUsageOnError, got: %q", output.Usage)
+	}
+}
+
+func TestCobraWrapper_UsageNotSetForCommandErrorsResemblingUsage(t *testing.T) {
+	root := &cobra.Command{Use: "root", SilenceUsage: true}
+	deploy := &cobra.Command{
+		Use:  "deploy [stack]",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// reads like cobra's argument count errors
+			return errors.New("accepts only json")
+		},
+	}
+	root.AddCommand(deploy)
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), root, WithUsageOnError(), WithoutMirror())
+
+	output, err := wrapper.Execute([]string{"deploy", "yaml"})
+	if err == nil || err.Error() != "accepts only json" {
+		t.Fatalf("Expected command error, got: %v", err)
+	}
+	if output.Usage != "" {
+		t.Errorf("Expected no usage for a command error, got: %q", output.Usage)
+	}
+}
+
+func TestCobraWrapper_UsageOnCobraErrors(t *testing.T) {
+	root := &cobra.Command{Use: "root", SilenceUsage: true}
+	root.AddCommand(&cobra.Command{
+		Use:  "deploy [stack]",
+		Args: cobra.ExactArgs(1),
+		Run:  func(cmd *cobra.Command, args []string) {},
+	})
+
+	destroy := &cobra.Command{
+		Use: "destroy",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
+	destroy.Flags().Bool("force", false, "Skip confirmation")
+	_ = destroy.MarkFlagRequired("force")
+	root.AddCommand(destroy)
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), root, WithUsageOnError(), WithoutMirror())
+
+	output, err := wrapper.Execute([]string{"bogus"})
+	if err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Fatalf("Expected unknown command error, got: %v", err)
+	}
+	if !strings.Contains(output.Usage, "root [command]") {
+		t.Errorf("Expected root usage, got: %q", output.Usage)
+	}
+
+	output, err = wrapper.Execute([]string{"destroy"})
+	if err == nil || !strings.Contains(err.Error(), "required flag(s)") {
+		t.Fatalf("Expected required flag error, got: %v", err)
+	}
+	if !strings.Contains(output.Usage, "root destroy [flags]") {
+		t.Errorf("Expected destroy usage, got: %q", output.Usage)
+	}
+
+	// a second wrapper over the same tree marks errors only once
+	NewCobraLambdaCLI(context.TODO(), root, WithUsageOnError(), WithoutMirror())
+	_, err = wrapper.Execute([]string{"deploy"})
+	var usageErr *usageError
+	if !errors.As(err, &usageErr) || errors.As(usageErr.err, &usageErr) {
+		t.Errorf("Expected the argument error to be marked once, got: %#v", err)
+	}
+}
@@ -2,9 +2,15 @@ package wrapper
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"regexp"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -13,37 +19,294 @@ import (
 type CobraLambdaOutput struct {
 	Stdout string `json:"stdout"`
 	Error  string `json:"error"`
+	// ExitCode is the process style exit status of the command, 1 when it returned an
+	// error unless the error carries its own code, see ExitError
+	ExitCode int `json:"exitCode"`
+	// TimedOut is set when the command was abandoned because the context deadline was near
+	// or the timeout annotated on the command elapsed
+	TimedOut bool `json:"timedOut,omitempty"`
+	// Encoding is set to "base64" when Stdout was base64 encoded due to invalid UTF-8
+	Encoding string `json:"encoding,omitempty"`
+	// Args echoes the arguments the command was executed with when WithEchoArgs is set
+	Args []string `json:"args,omitempty"`
+	// Usage holds the usage of the invoked command when it failed due to invalid
+	// flags or arguments and WithUsageOnError is set
+	Usage string `json:"usage,omitempty"`
+	// OutputFile is the path of the file holding captured output when WithOutputFile
+	// is set, Stdout is empty in that case
+	OutputFile string `json:"outputFile,omitempty"`
+	// OutputSize is the size in bytes of OutputFile
+	OutputSize int64 `json:"outputSize,omitempty"`
+	// DurationMs is how long the command ran in milliseconds, excluding setting up
+	// and draining the capture, to tell command time from cold start overhead
+	DurationMs int64 `json:"durationMs,omitempty"`
+	// Stderr holds output written to stderr when WithSeparateStderr or
+	// WithStderrFailThreshold is set, Stdout then holds only stdout
+	Stderr string `json:"stderr,omitempty"`
+	// StderrEncoding is set to "base64" when Stderr was base64 encoded due to invalid UTF-8
+	StderrEncoding string `json:"stderrEncoding,omitempty"`
+	// OutputBytes is the size in bytes of Stdout and Stderr, to keep an eye on
+	// Lambda's 6MB limit on synchronous responses
+	OutputBytes int `json:"outputBytes"`
+	// Sources counts output by the writer it came through when WithSourceTracking
+	// is set
+	Sources *OutputSources `json:"sources,omitempty"`
 }
 
 type CobraLambda struct {
 	cmd            *cobra.Command
 	originalStdout *os.File
 	originalStderr *os.File
+	// mirrorStdout and mirrorStderr receive a copy of captured output, nil disables mirroring
+	mirrorStdout   io.Writer
+	mirrorStderr   io.Writer
 	ctx            context.Context
-	mu             sync.Mutex
+	mu             *sync.Mutex
+	cancelMu       sync.Mutex
+	cancel         context.CancelFunc
+	deadlineMargin time.Duration
+	invalidUTF8    InvalidUTF8Mode
+	preExecHooks   []PreExecHook
+	postExecHooks  []PostExecHook
+
+	trimTrailingNewline      bool
+	withoutGeneratedCommands bool
+	flagParsingDisabled      bool
+	cleanErrors              bool
+	echoArgs                 bool
+	programName              string
+	contentTypeDetector      func(out string) string
+	errorLogger              *slog.Logger
+	redactPatterns           []*regexp.Regexp
+	flushInterval            time.Duration
+	flushSize                int
+	isolatedExecution        bool
+	lastRestoreOK            atomic.Bool
+	lineBufferedCapture      bool
+	stderrLogger             *slog.Logger
+	idempotencyStore         IdempotencyStore
+	captureBufferSize        int
+	usageOnError             bool
+	osArgsOverride           bool
+	outputFile               string
+	allowedCommands          []string
+	deniedCommands           []string
+	defaultArgs              []string
+	retry                    *retryPolicy
+	runningGoroutine         atomic.Uint64
+	recorder                 *recorder
+	hiddenFlags              []string
+	separateStderr           bool
+	stderrFailThreshold      int
+	stderrFailThresholdSet   bool
+	successExitCodes         []int
+	sourceTracking           bool
+	collapseCarriageReturns  bool
+	completionDisabled       bool
+	outputSizeWarning        int
+	timeoutFlag              string
+	maxArgs                  int
+	sink                     io.Writer
+	inheritStdin             bool
+	outputFormatFlag         string
+	stripANSI                bool
+	contextFactory           ContextFactory
+	// responseEncoder, when set, turns the output into the response returned
+	// by NewCobrLambdaHandler
+	responseEncoder func(ctx context.Context, output *CobraLambdaOutput) any
 }
 
-func NewCobraLambdaCLI(ctx context.Context, cmd *cobra.Command) *CobraLambda {
+// ErrNilCommand is returned by handlers constructed with a nil cobra command
+var ErrNilCommand = errors.New("cobra command must not be nil")
+
+// NewCobraLambdaCLI wraps cmd for execution with captured output. It panics with
+// ErrNilCommand when cmd is nil
+func NewCobraLambdaCLI(ctx context.Context, cmd *cobra.Command, opts ...Option) *CobraLambda {
+	if cmd == nil {
+		panic(ErrNilCommand)
+	}
+
 	cmd.SetContext(ctx)
-	return &CobraLambda{
+	return newCobraLambda(ctx, cmd, opts...)
+}
+
+func newCobraLambda(ctx context.Context, cmd *cobra.Command, opts ...Option) *CobraLambda {
+	w := &CobraLambda{
 		cmd:            cmd,
 		ctx:            ctx,
+		mu:             treeLock(cmd),
 		originalStdout: os.Stdout,
 		originalStderr: os.Stderr,
+		mirrorStdout:   os.Stdout,
+		mirrorStderr:   os.Stderr,
+		deadlineMargin: defaultDeadlineMargin,
+
+		outputSizeWarning: DefaultOutputSizeWarning,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if w.withoutGeneratedCommands {
+		removeGeneratedCommands(cmd)
+	} else if w.completionDisabled {
+		removeCompletionCommand(cmd)
+	}
+
+	if w.flagParsingDisabled {
+		disableFlagParsing(cmd)
+	}
+
+	if w.cleanErrors {
+		silenceErrors(cmd)
+	}
+
+	if w.outputFormatFlag != "" {
+		addFormatFlag(cmd, w.outputFormatFlag)
+	}
+
+	if w.programName != "" {
+		setProgramName(cmd, w.programName)
+	}
+
+	if len(w.hiddenFlags) > 0 {
+		hideFlags(cmd, w.hiddenFlags)
 	}
+
+	if w.usageOnError {
+		markUsageErrors(cmd)
+	}
+
+	return w
 }
 
 // Execute runs the Cobra command with the given arguments and captures all output
 // This method is thread-safe and will restore os.Stdout/Stderr even if the command panics
 // Note: Only one execution can run at a time per wrapper instance to avoid interference
 func (w *CobraLambda) Execute(args []string) (*CobraLambdaOutput, error) {
+	done, err := trackExecution()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	return w.execute(nil, args, nil)
+}
+
+// execute is the shared implementation of Execute, ExecuteContext and
+// ExecuteStream. The command runs under ctx, or the context of the command
+// when ctx is nil. When tee is non-nil, captured output is also written to it
+// as it is produced
+func (w *CobraLambda) execute(ctx context.Context, args []string, tee io.Writer) (*CobraLambdaOutput, error) {
+	if err := w.checkReentrant(ctx); err != nil {
+		return nil, err
+	}
+
+	if ctx == nil {
+		ctx = w.restingContext()
+	}
+
+	if err := w.checkMaxArgs(args); err != nil {
+		return nil, err
+	}
+
+	args, flagTimeout, err := w.extractTimeoutFlag(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 && len(w.defaultArgs) > 0 {
+		args = append([]string{}, w.defaultArgs...)
+	}
+
+	if err := w.checkAllowed(args); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		output, err := w.executeOnce(ctx, args, tee, flagTimeout)
+		if !w.shouldRetry(ctx, attempt, output, err) {
+			return output, err
+		}
+
+		if waitErr := w.waitRetry(ctx, attempt); waitErr != nil {
+			return output, err
+		}
+	}
+}
+
+// executeOnce runs the command once with fresh capture pipes and buffer, first
+// resetting flags to their defaults
+func (w *CobraLambda) executeOnce(ctx context.Context, args []string, tee io.Writer, flagTimeout time.Duration) (*CobraLambdaOutput, error) {
+	release, err := acquireGlobalSlot()
+	if err != nil {
+		return nil, err
+	}
+
+	// the global slot is held as long as the tree lock, an abandoned command
+	// still running counts against the limit
 	w.mu.Lock()
-	defer w.mu.Unlock()
+	unlock := func() {
+		w.mu.Unlock()
+		release()
+	}
+	defer func() { unlock() }()
+
+	// flags set by a previous execution or failed attempt must not leak into this one
+	if err := resetCommand(w.cmd); err != nil {
+		return nil, err
+	}
+
+	// resolving the command reads the tree, which cobra changes while executing
+	if err := w.checkCompletion(args); err != nil {
+		return nil, err
+	}
+
+	if err := checkRunnable(w.cmd, args); err != nil {
+		return nil, err
+	}
+
+	if err := w.checkConfirmation(ctx, args); err != nil {
+		return nil, err
+	}
+
+	// commands reading os.Stdin must not block on the Lambda runtime's stdin
+	restoreStdin, err := w.redirectStdin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer restoreStdin()
 
 	sharedBuffer := &threadSafeBuffer{}
 
+	var capture io.Writer = sharedBuffer
+
+	var outputFile *os.File
+	sink := w.newSinkWriter()
+	if sink != nil {
+		capture = sink
+	} else {
+		if outputFile, err = w.createOutputFile(); err != nil {
+			return nil, err
+		}
+		if outputFile != nil {
+			capture = outputFile
+		}
+	}
+
+	if tee != nil {
+		capture = io.MultiWriter(capture, tee)
+	}
+
+	stderrBuffer, stderrCapture := w.stderrCapture(tee)
+	if stderrCapture == nil {
+		stderrCapture = capture
+	}
+
 	stdoutReader, stdoutWriter, err := os.Pipe()
 	if err != nil {
+		w.discardOutputFile(outputFile)
 		return nil, err
 	}
 
@@ -51,14 +314,12 @@ func (w *CobraLambda) Execute(args []string) (*CobraLambdaOutput, error) {
 	if err != nil {
 		_ = stdoutWriter.Close()
 		_ = stdoutReader.Close()
+		w.discardOutputFile(outputFile)
 		return nil, err
 	}
 
 	// Ensure we restore original stdout/stderr even on panic
-	defer func() {
-		os.Stdout = w.originalStdout
-		os.Stderr = w.originalStderr
-	}()
+	defer w.restoreStdio()
 
 	os.Stdout = stdoutWriter
 	os.Stderr = stderrWriter
@@ -66,28 +327,101 @@ func (w *CobraLambda) Execute(args []string) (*CobraLambdaOutput, error) {
 	done := make(chan bool, 2)
 	var wg sync.WaitGroup
 
+	// a panicking writer, e.g. a mirror whose file was closed, must not crash the
+	// process from a drain goroutine, it is recorded and returned instead
+	drainErrs := &drainErrors{}
+	tracker := w.newSourceTracker()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		mw := io.MultiWriter(sharedBuffer, w.originalStdout)
-		_, _ = io.Copy(mw, stdoutReader)
+		defer drainErrs.recoverPanic()
+		w.drain(mirrored(drainErrs.guard(capture), drainErrs.guard(w.mirrorStdout)), tracker.pipeReader(stdoutReader))
 		done <- true
 	}()
 
 	wg.Add(1)
+	stderrLog := w.stderrLogWriter(ctx)
 	go func() {
 		defer wg.Done()
-		mw := io.MultiWriter(sharedBuffer, w.originalStderr)
-		_, _ = io.Copy(mw, stderrReader)
+		defer drainErrs.recoverPanic()
+		dst := mirrored(drainErrs.guard(stderrCapture), drainErrs.guard(w.mirrorStderr))
+		if stderrLog != nil {
+			defer stderrLog.Flush()
+			dst = io.MultiWriter(dst, drainErrs.guard(stderrLog))
+		}
+		w.drain(dst, tracker.pipeReader(stderrReader))
 		done <- true
 	}()
 
-	// when set to nil, cobra will use stdout/stderr
-	w.cmd.SetOut(nil)
-	w.cmd.SetErr(nil)
+	// when set to nil, cobra will use stdout/stderr. This is applied to the whole
+	// tree right before execution so writers replaced by a previous run are reset
+	redirectOutput(w.cmd)
+	restoreIn := w.applyStdin(ctx)
+
+	// the writers cobra should resolve to, checked after the run for commands
+	// that replaced them
+	var cobraOut, cobraErr io.Writer = stdoutWriter, stderrWriter
+	if tracker != nil {
+		cobraOut, cobraErr = tracker.cobraWriter(stdoutWriter), tracker.cobraWriter(stderrWriter)
+		w.cmd.SetOut(cobraOut)
+		w.cmd.SetErr(cobraErr)
+	}
+
+	// cobra falls back to os.Args when args are nil which on Lambda are the runtime's args
+	if args == nil {
+		args = []string{}
+	}
 	w.cmd.SetArgs(args)
 
-	execErr := w.cmd.Execute()
+	defer w.overrideOsArgs(args)()
+
+	exitCodes := &exitCodeSlot{}
+
+	// an abandoned command still uses the tree until it unwinds, resetting it and
+	// the next execution wait for that
+	var unwound <-chan struct{}
+	unlockNow := unlock
+	defer func() {
+		settle := func() {
+			restoreIn()
+			if tracker != nil {
+				// the tracking writers point at the closed pipes from here on
+				w.cmd.SetOut(nil)
+				w.cmd.SetErr(nil)
+			}
+		}
+		if unwound == nil {
+			settle()
+			return
+		}
+		unlock = func() {
+			go func() {
+				<-unwound
+				settle()
+				unlockNow()
+			}()
+		}
+	}()
+
+	start := time.Now()
+	timedOut, unwound, execErr := w.run(ctx, args, flagTimeout, exitCodes)
+	duration := time.Since(start)
+
+	code, success := w.successExitCode(execErr)
+	if success && !timedOut {
+		execErr = nil
+	}
+
+	if set, ok := exitCodes.get(); ok && !timedOut {
+		code = set
+	}
+
+	if unwound == nil {
+		for _, path := range overriddenOutput(w.cmd, cobraOut, cobraErr) {
+			_, _ = fmt.Fprintf(w.originalStderr, "wrapper: command %q replaced its output writer, output written to it was not captured\n", path)
+		}
+	}
 
 	_ = stdoutWriter.Close()
 	_ = stderrWriter.Close()
@@ -98,19 +432,304 @@ func (w *CobraLambda) Execute(args []string) (*CobraLambdaOutput, error) {
 	_ = stdoutReader.Close()
 	_ = stderrReader.Close()
 
+	// restore only once the drains have read everything written to the pipes so
+	// the swap is ordered after the command's writes
+	w.verifyRestored(stdoutWriter, stderrWriter)
+
+	if err := drainErrs.Err(); err != nil && execErr == nil {
+		execErr = err
+	}
+
+	output := &CobraLambdaOutput{
+		ExitCode:   code,
+		TimedOut:   timedOut,
+		DurationMs: duration.Milliseconds(),
+	}
+
+	if tracker != nil {
+		output.Sources = tracker.sources()
+	}
+
+	switch {
+	case sink != nil:
+		if err := sink.Err(); err != nil && execErr == nil {
+			execErr = err
+		}
+	case outputFile != nil:
+		if err := finishOutputFile(output, outputFile); err != nil && execErr == nil {
+			execErr = err
+		}
+	default:
+		output.Stdout = w.transformOutput(sharedBuffer.String())
+	}
+
+	if stderrBuffer != nil {
+		stderr := stderrBuffer.String()
+		output.Stderr = w.transformOutput(stderr)
+
+		if err := w.checkStderrThreshold(stderr); err != nil && execErr == nil {
+			execErr = err
+		}
+	}
+
+	if execErr != nil && output.ExitCode == 0 {
+		output.ExitCode = exitCode(execErr)
+	}
+
+	if w.usageOnError && execErr != nil && !timedOut && isUsageError(w.cmd, args, execErr) {
+		output.Usage = usageFor(w.cmd, args)
+	}
+
+	if w.echoArgs {
+		output.Args = append([]string{}, args...)
+	}
+
+	if err := w.encodeOutput(output); err != nil && execErr == nil {
+		execErr = err
+	}
+
+	output.OutputBytes = outputBytes(output)
+
+	return output, execErr
+}
+
+// restoreStdio points os.Stdout and os.Stderr back at the original files
+func (w *CobraLambda) restoreStdio() {
 	os.Stdout = w.originalStdout
 	os.Stderr = w.originalStderr
+}
+
+// verifyRestored records whether os.Stdout and os.Stderr are still the capture
+// pipes stdout and stderr, logging when something else replaced them, and
+// restores the original files
+func (w *CobraLambda) verifyRestored(stdout, stderr *os.File) {
+	ok := os.Stdout == stdout && os.Stderr == stderr
+	w.lastRestoreOK.Store(ok)
+
+	if !ok {
+		_, _ = fmt.Fprintln(w.originalStderr, "wrapper: os.Stdout/os.Stderr were replaced while the command ran, restoring them")
+	}
+	w.restoreStdio()
+}
 
-	return &CobraLambdaOutput{
-		Stdout: sharedBuffer.String(),
-	}, execErr
+// LastRestoreOK reports whether the last execution found os.Stdout and
+// os.Stderr still pointing at the capture pipes when restoring the original files
+func (w *CobraLambda) LastRestoreOK() bool {
+	return w.lastRestoreOK.Load()
+}
+
+// run executes the command, giving up once the context deadline is within
+// deadlineMargin, the command's annotated timeout or flagTimeout elapses or the
+// execution is cancelled. When giving up the command context is cancelled and the command is
+// left to unwind in the background while captured output is returned. The
+// returned channel is then closed once the command returned, the next
+// execution of the tree by any wrapper, including the one built by the next
+// handler invocation, waits for it so the abandoned command never shares the
+// tree with it. Commands should return promptly once cmd.Context() is done,
+// one that never does blocks later executions
+func (w *CobraLambda) run(ctx context.Context, args []string, flagTimeout time.Duration, exitCodes *exitCodeSlot) (bool, <-chan struct{}, error) {
+	if err := ctx.Err(); err != nil {
+		return false, nil, err
+	}
+
+	cmdTimeout, path, err := commandTimeout(w.cmd, args)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if flagTimeout > 0 && (cmdTimeout == 0 || flagTimeout < cmdTimeout) {
+		cmdTimeout = flagTimeout
+		path = w.cmd.CommandPath()
+		if target, _, findErr := w.cmd.Find(args); findErr == nil && target != nil {
+			path = target.CommandPath()
+		}
+	}
+
+	var runCtx context.Context
+	var cancel context.CancelFunc
+	if cmdTimeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, cmdTimeout)
+	} else {
+		runCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	// lets CheckErr hand its error back instead of exiting the process
+	checkErr := &checkErrSlot{}
+	runCtx = context.WithValue(runCtx, checkErrKey{}, checkErr)
+	runCtx = context.WithValue(runCtx, exitCodeKey{}, exitCodes)
+
+	unwound := make(chan struct{})
+	runCtx = context.WithValue(runCtx, runningKey{}, &runningMark{lock: w.mu, unwound: unwound})
+
+	w.setCancel(cancel)
+	defer w.setCancel(nil)
+
+	// cobra only hands the context to subcommands without one, which would
+	// otherwise keep the cancelled context of the previous execution
+	clearSubcommandContexts(w.cmd)
+	resting := w.cmd.Context()
+
+	result := make(chan runResult, 1)
+	go func() {
+		defer close(unwound)
+		defer w.markRunning()()
+
+		res := runResult{exited: true}
+		defer func() {
+			if r := recover(); r != nil {
+				res = runResult{panicked: true, panicValue: r}
+			}
+			// the run context is cancelled once run returns, the next execution
+			// must not inherit it
+			w.cmd.SetContext(resting)
+			result <- res
+		}()
+		err := w.cmd.ExecuteContext(runCtx)
+		res = runResult{err: err}
+	}()
+
+	var timeout <-chan time.Time
+	if deadline, ok := ctx.Deadline(); ok {
+		timer := time.NewTimer(time.Until(deadline) - w.deadlineMargin)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case res := <-result:
+		if res.panicked && w.isolatedExecution {
+			return false, nil, fmt.Errorf("%w: %v", ErrCommandPanicked, res.panicValue)
+		}
+		if res.panicked {
+			panic(res.panicValue)
+		}
+		if res.exited {
+			if err := checkErr.get(); err != nil {
+				return false, nil, err
+			}
+			return false, nil, ErrCommandExited
+		}
+		return false, nil, res.err
+	case <-timeout:
+		return true, unwound, nil
+	case <-runCtx.Done():
+		if err := ctx.Err(); err != nil {
+			return false, unwound, err
+		}
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			return true, unwound, fmt.Errorf("wrapper: command %q exceeded its %s timeout: %w", path, cmdTimeout, context.DeadlineExceeded)
+		}
+		return false, unwound, context.Canceled
+	}
+}
+
+// restingContext returns the context the command runs under when none is
+// given, waiting for an abandoned command still changing it
+func (w *CobraLambda) restingContext() context.Context {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.baseContext()
+}
+
+// baseContext returns the context set on the command, falling back to the
+// wrapper's context and then context.Background when none was set
+func (w *CobraLambda) baseContext() context.Context {
+	if ctx := w.cmd.Context(); ctx != nil {
+		return ctx
+	}
+	if w.ctx != nil {
+		return w.ctx
+	}
+	return context.Background()
+}
+
+// clearSubcommandContexts removes the context of every command below cmd so
+// cobra passes the one cmd is executed with down to them
+func clearSubcommandContexts(cmd *cobra.Command) {
+	for _, sub := range cmd.Commands() {
+		sub.SetContext(nil)
+		clearSubcommandContexts(sub)
+	}
+}
+
+// runResult carries the outcome of the command goroutine back to run
+type runResult struct {
+	err        error
+	panicked   bool
+	panicValue any
+	// exited is set when the command goroutine ended without returning or panicking
+	exited bool
+}
+
+// Cancel stops the execution in progress, if any. The command context is
+// cancelled and Execute returns right away with the output captured so far and
+// context.Canceled. Commands should watch cmd.Context() to stop promptly
+func (w *CobraLambda) Cancel() {
+	w.cancelMu.Lock()
+	defer w.cancelMu.Unlock()
+
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
+
+func (w *CobraLambda) setCancel(cancel context.CancelFunc) {
+	w.cancelMu.Lock()
+	defer w.cancelMu.Unlock()
+
+	w.cancel = cancel
 }
 
 // ExecuteWithContext is a convenience method that runs Execute with the provided context overriding
-// context passed in from NewCobraLambda and restoring to original context after execution
+// context passed in from NewCobraLambda and restoring to original context after execution.
+// Cancelling ctx, e.g. when an HTTP client disconnects, stops waiting for the command and
+// returns ctx.Err() with the output captured so far. A ctx that is already done is
+// returned without running the command
 func (w *CobraLambda) ExecuteContext(ctx context.Context, args []string) (*CobraLambdaOutput, error) {
-	w.cmd.SetContext(ctx)
-	output, err := w.Execute(args)
-	w.cmd.SetContext(w.ctx)
-	return output, err
+	done, err := trackExecution()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	return w.execute(ctx, args, nil)
+}
+
+// mirrored returns a writer copying to capture and, when set, to mirror
+func mirrored(capture io.Writer, mirror io.Writer) io.Writer {
+	if mirror == nil {
+		return capture
+	}
+	return io.MultiWriter(capture, mirror)
+}
+
+// redirectOutput clears the out and err writers of every command in the tree so
+// cobra falls back to os.Stdout and os.Stderr, which are swapped for the capture pipes
+func redirectOutput(cmd *cobra.Command) {
+	cmd.SetOut(nil)
+	cmd.SetErr(nil)
+
+	for _, sub := range cmd.Commands() {
+		redirectOutput(sub)
+	}
+}
+
+// overriddenOutput returns the paths of commands whose out or err writer no longer
+// resolves to out and errW, the capture pipes, meaning the command called SetOut or
+// SetErr itself. The os.Stdout/os.Stderr swap still captures anything written
+// there as a backstop
+func overriddenOutput(cmd *cobra.Command, out, errW io.Writer) []string {
+	var paths []string
+
+	if cmd.OutOrStdout() != out || cmd.ErrOrStderr() != errW {
+		paths = append(paths, cmd.CommandPath())
+	}
+
+	for _, sub := range cmd.Commands() {
+		paths = append(paths, overriddenOutput(sub, out, errW)...)
+	}
+
+	return paths
 }
@@ -2,11 +2,14 @@ package wrapper
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -211,3 +214,741 @@ func TestCobraWrapper_EmptyStdout(t *testing.T) {
 		t.Errorf("Expected empty Stdout, got: %s", output.Stdout)
 	}
 }
+
+func TestCobraWrapper_DeadlineReturnsPartialOutput(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("partial output")
+			select {
+			case <-cmd.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	wrapper := NewCobraLambdaCLI(ctx, cmd, WithDeadlineMargin(100*time.Millisecond))
+
+	start := time.Now()
+	output, err := wrapper.Execute([]string{})
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !output.TimedOut {
+		t.Error("Expected TimedOut to be set")
+	}
+	if elapsed >= 300*time.Millisecond {
+		t.Errorf("Expected Execute to return before the deadline, took %v", elapsed)
+	}
+	if !strings.Contains(output.Stdout, "partial output") {
+		t.Errorf("Partial output not captured. Got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_DeadlineNotReached(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("fast command")
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	wrapper := NewCobraLambdaCLI(ctx, cmd)
+	output, err := wrapper.Execute([]string{})
+
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.TimedOut {
+		t.Error("Expected TimedOut to be false")
+	}
+	if !strings.Contains(output.Stdout, "fast command") {
+		t.Errorf("Stdout missing expected text. Got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_CommandOverridesOutput(t *testing.T) {
+	rootCmd := &cobra.Command{
+		Use: "root",
+	}
+
+	runs := 0
+	subCmd := &cobra.Command{
+		Use: "sub",
+		Run: func(cmd *cobra.Command, args []string) {
+			runs++
+			if runs == 1 {
+				// Redirect cobra output away from the capture on the first run only
+				cmd.SetOut(io.Discard)
+			}
+			cmd.Println("cobra output")
+			fmt.Println("stdout backstop")
+		},
+	}
+	rootCmd.AddCommand(subCmd)
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), rootCmd)
+
+	output, err := wrapper.Execute([]string{"sub"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "stdout backstop") {
+		t.Errorf("Expected os.Stdout output to be captured. Got: %s", output.Stdout)
+	}
+
+	// The overridden writer must not leak into the next execution
+	output, err = wrapper.Execute([]string{"sub"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "cobra output") {
+		t.Errorf("Expected cobra output to be captured after reset. Got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_TrimTrailingNewline(t *testing.T) {
+	tests := []struct {
+		name     string
+		print    func(cmd *cobra.Command)
+		opts     []Option
+		expected string
+	}{
+		{
+			name:     "verbatim by default",
+			print:    func(cmd *cobra.Command) { cmd.Println("hello") },
+			expected: "hello\n",
+		},
+		{
+			name:     "trims single newline",
+			print:    func(cmd *cobra.Command) { cmd.Println("hello") },
+			opts:     []Option{WithTrimTrailingNewline()},
+			expected: "hello",
+		},
+		{
+			name:     "trims only one newline",
+			print:    func(cmd *cobra.Command) { cmd.Print("hello\n\n") },
+			opts:     []Option{WithTrimTrailingNewline()},
+			expected: "hello\n",
+		},
+		{
+			name:     "no trailing newline",
+			print:    func(cmd *cobra.Command) { cmd.Printf("hello") },
+			opts:     []Option{WithTrimTrailingNewline()},
+			expected: "hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{
+				Use: "test",
+				Run: func(cmd *cobra.Command, args []string) {
+					tt.print(cmd)
+				},
+			}
+
+			wrapper := NewCobraLambdaCLI(context.TODO(), cmd, tt.opts...)
+			output, err := wrapper.Execute([]string{})
+
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if output.Stdout != tt.expected {
+				t.Errorf("Expected %q, got: %q", tt.expected, output.Stdout)
+			}
+		})
+	}
+}
+
+func TestCobraWrapper_ExitCode(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("command failed")
+			}
+			return nil
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd)
+
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.ExitCode != 0 {
+		t.Errorf("Expected exit code 0, got: %d", output.ExitCode)
+	}
+
+	output, _ = wrapper.Execute([]string{"fail"})
+	if output.ExitCode != 1 {
+		t.Errorf("Expected exit code 1, got: %d", output.ExitCode)
+	}
+}
+
+func TestCobraWrapper_Cancel(t *testing.T) {
+	started := make(chan struct{})
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("started")
+			close(started)
+			select {
+			case <-cmd.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.Background(), cmd)
+
+	type result struct {
+		output *CobraLambdaOutput
+		err    error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		output, err := wrapper.Execute([]string{})
+		done <- result{output, err}
+	}()
+
+	<-started
+	wrapper.Cancel()
+
+	select {
+	case res := <-done:
+		if !errors.Is(res.err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got: %v", res.err)
+		}
+		if !strings.Contains(res.output.Stdout, "started") {
+			t.Errorf("Expected captured output before cancel. Got: %s", res.output.Stdout)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Execute did not return after Cancel")
+	}
+}
+
+// assertReusable fails unless both the root and a subcommand of wrapper run
+// with a live context
+func assertReusable(t *testing.T, wrapper *CobraLambda) {
+	t.Helper()
+
+	for _, args := range [][]string{{}, {"check"}, {"check"}} {
+		output, err := wrapper.Execute(args)
+		if err != nil {
+			t.Fatalf("Expected Execute %v to succeed, got: %v", args, err)
+		}
+		if !strings.HasSuffix(output.Stdout, "ok") {
+			t.Errorf("Unexpected output for %v: %q", args, output.Stdout)
+		}
+	}
+}
+
+func TestCobraWrapper_ExecuteAfterCancel(t *testing.T) {
+	// "slow" blocks until its context is done, the others fail when their
+	// context already is
+	cmd := &cobra.Command{
+		Use: "root",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.Print("root ok")
+			return cmd.Context().Err()
+		},
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use: "slow",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Println("started")
+			<-cmd.Context().Done()
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use: "check",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.Print("check ok")
+			return cmd.Context().Err()
+		},
+	})
+
+	wrapper := NewCobraLambdaCLI(context.Background(), cmd, WithoutMirror())
+
+	// a first execution must not leave its context on the subcommands
+	assertReusable(t, wrapper)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := wrapper.Execute([]string{"slow"})
+		done <- err
+	}()
+
+	// Cancel is a no-op until the command runs
+	for {
+		wrapper.Cancel()
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("Expected context.Canceled, got: %v", err)
+			}
+			assertReusable(t, wrapper)
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestCobraWrapper_ExecuteAfterDeadline(t *testing.T) {
+	// "slow" blocks until its context is done, the others fail when their
+	// context already is
+	cmd := &cobra.Command{
+		Use: "root",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.Print("root ok")
+			return cmd.Context().Err()
+		},
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use: "slow",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Println("started")
+			<-cmd.Context().Done()
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use: "check",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.Print("check ok")
+			return cmd.Context().Err()
+		},
+	})
+
+	wrapper := NewCobraLambdaCLI(context.Background(), cmd, WithoutMirror(), WithDeadlineMargin(50*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	output, err := wrapper.ExecuteContext(ctx, []string{"slow"})
+	if err != nil {
+		t.Fatalf("ExecuteContext failed: %v", err)
+	}
+	if !output.TimedOut {
+		t.Fatal("Expected TimedOut to be set")
+	}
+
+	assertReusable(t, wrapper)
+}
+
+func TestCobraWrapper_CancelWithoutExecution(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Println("ran")
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.Background(), cmd)
+
+	// Cancel before any execution is a no-op and must not affect later runs
+	wrapper.Cancel()
+
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "ran") {
+		t.Errorf("Stdout missing expected text. Got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_Mirror(t *testing.T) {
+	newCmd := func() *cobra.Command {
+		return &cobra.Command{
+			Use: "test",
+			Run: func(cmd *cobra.Command, args []string) {
+				fmt.Println("to stdout")
+				fmt.Fprintln(os.Stderr, "to stderr")
+			},
+		}
+	}
+
+	// Stub the mirror writers that default to the original stdout/stderr
+	stdoutMirror := &threadSafeBuffer{}
+	stderrMirror := &threadSafeBuffer{}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), newCmd())
+	wrapper.mirrorStdout = stdoutMirror
+	wrapper.mirrorStderr = stderrMirror
+
+	if _, err := wrapper.Execute([]string{}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if stdoutMirror.String() != "to stdout\n" {
+		t.Errorf("Expected stdout to be mirrored, got: %q", stdoutMirror.String())
+	}
+	if stderrMirror.String() != "to stderr\n" {
+		t.Errorf("Expected stderr to be mirrored, got: %q", stderrMirror.String())
+	}
+
+	// Stub the original stdout/stderr picked up at construction with a file
+	stub, err := os.CreateTemp(t.TempDir(), "original")
+	if err != nil {
+		t.Fatalf("Failed to create stub: %v", err)
+	}
+	defer stub.Close()
+
+	originalStdout, originalStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stub, stub
+	defer func() {
+		os.Stdout, os.Stderr = originalStdout, originalStderr
+	}()
+
+	wrapper = NewCobraLambdaCLI(context.TODO(), newCmd(), WithoutMirror())
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output.Stdout, "to stdout") || !strings.Contains(output.Stdout, "to stderr") {
+		t.Errorf("Expected output to still be captured. Got: %s", output.Stdout)
+	}
+
+	mirrored, err := os.ReadFile(stub.Name())
+	if err != nil {
+		t.Fatalf("Failed to read stub: %v", err)
+	}
+	if len(mirrored) != 0 {
+		t.Errorf("Expected nothing mirrored to the original writers, got: %q", mirrored)
+	}
+}
+
+func TestCobraWrapper_ExecuteContextDeadline(t *testing.T) {
+	var deadline time.Time
+	var hasDeadline bool
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			deadline, hasDeadline = cmd.Context().Deadline()
+		},
+	}
+
+	baseCtx := context.Background()
+	wrapper := NewCobraLambdaCLI(baseCtx, cmd)
+
+	expected := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), expected)
+	defer cancel()
+
+	if _, err := wrapper.ExecuteContext(ctx, []string{}); err != nil {
+		t.Fatalf("ExecuteContext failed: %v", err)
+	}
+
+	if !hasDeadline {
+		t.Fatal("Expected deadline to be visible via cmd.Context() during Run")
+	}
+	if !deadline.Equal(expected) {
+		t.Errorf("Expected deadline %v, got: %v", expected, deadline)
+	}
+
+	if _, ok := cmd.Context().Deadline(); ok {
+		t.Error("Expected original context without deadline to be restored after execution")
+	}
+}
+
+func TestCobraWrapper_ExecuteContextRestoredAfterPanic(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			panic("boom")
+		},
+	}
+
+	baseCtx := context.Background()
+	wrapper := NewCobraLambdaCLI(baseCtx, cmd)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("Expected panic to propagate from ExecuteContext")
+			}
+		}()
+		_, _ = wrapper.ExecuteContext(ctx, []string{})
+	}()
+
+	if cmd.Context() != baseCtx {
+		t.Error("Expected original context to be restored after a panic")
+	}
+}
+
+func TestCobraWrapper_RestoreAfterConcurrentWrites(t *testing.T) {
+	originalStdout := os.Stdout
+	originalStderr := os.Stderr
+
+	line := strings.Repeat("x", 1023) + "\n"
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 32; j++ {
+						fmt.Fprint(os.Stdout, line)
+						fmt.Fprint(os.Stderr, line)
+					}
+				}()
+			}
+			wg.Wait()
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror())
+
+	for run := 0; run < 3; run++ {
+		output, err := wrapper.Execute([]string{})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+
+		if expected := 8 * 32 * 2 * len(line); len(output.Stdout) != expected {
+			t.Errorf("Expected %d bytes of output, got: %d", expected, len(output.Stdout))
+		}
+		if !wrapper.LastRestoreOK() {
+			t.Error("Expected LastRestoreOK to be true")
+		}
+		if os.Stdout != originalStdout || os.Stderr != originalStderr {
+			t.Fatal("os.Stdout/os.Stderr were not restored")
+		}
+	}
+}
+
+func TestCobraWrapper_LastRestoreOKDetectsSwap(t *testing.T) {
+	originalStdout := os.Stdout
+	defer func() {
+		os.Stdout = originalStdout
+	}()
+
+	replacement, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer replacement.Close()
+
+	root := &cobra.Command{Use: "root"}
+	root.AddCommand(&cobra.Command{
+		Use: "swap",
+		Run: func(cmd *cobra.Command, args []string) {
+			os.Stdout = replacement
+		},
+	})
+	root.AddCommand(&cobra.Command{
+		Use: "plain",
+		Run: func(cmd *cobra.Command, args []string) {},
+	})
+	wrapper := NewCobraLambdaCLI(context.TODO(), root, WithoutMirror())
+
+	if _, err := wrapper.Execute([]string{"swap"}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if wrapper.LastRestoreOK() {
+		t.Error("Expected LastRestoreOK to be false after the command swapped os.Stdout")
+	}
+	if os.Stdout != originalStdout {
+		t.Error("Expected os.Stdout to be restored after the swap was detected")
+	}
+
+	if _, err := wrapper.Execute([]string{"plain"}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !wrapper.LastRestoreOK() {
+		t.Error("Expected LastRestoreOK to be true")
+	}
+}
+
+func TestCobraWrapper_NilCommand(t *testing.T) {
+	defer func() {
+		r := recover()
+		err, ok := r.(error)
+		if !ok || !errors.Is(err, ErrNilCommand) {
+			t.Errorf("Expected panic with ErrNilCommand, got: %v", r)
+		}
+		if ok && err.Error() != "cobra command must not be nil" {
+			t.Errorf("Got: %v", err)
+		}
+	}()
+
+	NewCobraLambdaCLI(context.TODO(), nil)
+}
+
+func TestCobraWrapper_ExecuteContextCancelled(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("partial output")
+			close(started)
+			// ignores cancellation, the wrapper must stop waiting regardless
+			<-release
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.Background(), cmd, WithoutMirror())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	start := time.Now()
+	output, err := wrapper.ExecuteContext(ctx, []string{})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Expected ExecuteContext to return promptly after cancellation")
+	}
+	if !strings.Contains(output.Stdout, "partial output") {
+		t.Errorf("Expected partial output, got: %s", output.Stdout)
+	}
+}
+
+func TestCobraWrapper_ExecuteContextAlreadyCancelled(t *testing.T) {
+	ran := false
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			ran = true
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewCobraLambdaCLI(context.Background(), cmd).ExecuteContext(ctx, []string{})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+	if ran {
+		t.Error("Expected command not to run with a cancelled context")
+	}
+}
+
+func TestCobraWrapper_MirrorSelective(t *testing.T) {
+	tests := []struct {
+		stdout bool
+		stderr bool
+	}{
+		{stdout: true, stderr: true},
+		{stdout: true, stderr: false},
+		{stdout: false, stderr: true},
+		{stdout: false, stderr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("stdout=%t,stderr=%t", tt.stdout, tt.stderr), func(t *testing.T) {
+			// Stub the original stdout/stderr picked up at construction with files
+			stdoutStub, err := os.CreateTemp(t.TempDir(), "stdout")
+			if err != nil {
+				t.Fatalf("Failed to create stub: %v", err)
+			}
+			defer stdoutStub.Close()
+
+			stderrStub, err := os.CreateTemp(t.TempDir(), "stderr")
+			if err != nil {
+				t.Fatalf("Failed to create stub: %v", err)
+			}
+			defer stderrStub.Close()
+
+			originalStdout, originalStderr := os.Stdout, os.Stderr
+			os.Stdout, os.Stderr = stdoutStub, stderrStub
+			defer func() {
+				os.Stdout, os.Stderr = originalStdout, originalStderr
+			}()
+
+			cmd := &cobra.Command{
+				Use: "test",
+				Run: func(cmd *cobra.Command, args []string) {
+					fmt.Println("to stdout")
+					fmt.Fprintln(os.Stderr, "to stderr")
+				},
+			}
+
+			wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithMirror(tt.stdout, tt.stderr))
+			output, err := wrapper.Execute([]string{})
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if !strings.Contains(output.Stdout, "to stdout") || !strings.Contains(output.Stdout, "to stderr") {
+				t.Errorf("Expected output to still be captured. Got: %s", output.Stdout)
+			}
+
+			for _, stub := range []struct {
+				file     *os.File
+				expected string
+				mirrored bool
+			}{
+				{stdoutStub, "to stdout\n", tt.stdout},
+				{stderrStub, "to stderr\n", tt.stderr},
+			} {
+				data, err := os.ReadFile(stub.file.Name())
+				if err != nil {
+					t.Fatalf("Failed to read stub: %v", err)
+				}
+
+				expected := ""
+				if stub.mirrored {
+					expected = stub.expected
+				}
+				if string(data) != expected {
+					t.Errorf("Expected %q mirrored to %s, got: %q", expected, stub.file.Name(), data)
+				}
+			}
+		})
+	}
+}
+
+func TestCobraWrapper_Duration(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 0 {
+				time.Sleep(150 * time.Millisecond)
+			}
+		},
+	}
+
+	wrapper := NewCobraLambdaCLI(context.TODO(), cmd, WithoutMirror())
+
+	output, err := wrapper.Execute([]string{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.DurationMs < 0 || output.DurationMs >= 150 {
+		t.Errorf("Expected a short non-negative duration, got: %dms", output.DurationMs)
+	}
+
+	output, err = wrapper.Execute([]string{"sleep"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output.DurationMs < 150 || output.DurationMs > 1000 {
+		t.Errorf("Expected a duration close to 150ms, got: %dms", output.DurationMs)
+	}
+}
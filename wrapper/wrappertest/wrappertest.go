@@ -0,0 +1,66 @@
+// Package wrappertest provides assertion helpers for testing handlers built
+// with the wrapper package
+package wrappertest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JayJamieson/cobra-lambda/wrapper"
+)
+
+// AssertStdoutContains fails the test if out is nil or its Stdout does not contain substr
+func AssertStdoutContains(t testing.TB, out *wrapper.CobraLambdaOutput, substr string) {
+	t.Helper()
+
+	if out == nil {
+		t.Errorf("expected output containing %q, got nil output", substr)
+		return
+	}
+
+	if !strings.Contains(out.Stdout, substr) {
+		t.Errorf("expected Stdout to contain %q, got: %q", substr, out.Stdout)
+	}
+}
+
+// AssertStdoutNotContains fails the test if out is nil or its Stdout contains substr
+func AssertStdoutNotContains(t testing.TB, out *wrapper.CobraLambdaOutput, substr string) {
+	t.Helper()
+
+	if out == nil {
+		t.Errorf("expected output not containing %q, got nil output", substr)
+		return
+	}
+
+	if strings.Contains(out.Stdout, substr) {
+		t.Errorf("expected Stdout not to contain %q, got: %q", substr, out.Stdout)
+	}
+}
+
+// AssertStdoutEquals fails the test if out is nil or its Stdout is not exactly expected
+func AssertStdoutEquals(t testing.TB, out *wrapper.CobraLambdaOutput, expected string) {
+	t.Helper()
+
+	if out == nil {
+		t.Errorf("expected Stdout %q, got nil output", expected)
+		return
+	}
+
+	if out.Stdout != expected {
+		t.Errorf("expected Stdout %q, got: %q", expected, out.Stdout)
+	}
+}
+
+// AssertExitCode fails the test if out is nil or its ExitCode is not code
+func AssertExitCode(t testing.TB, out *wrapper.CobraLambdaOutput, code int) {
+	t.Helper()
+
+	if out == nil {
+		t.Errorf("expected exit code %d, got nil output", code)
+		return
+	}
+
+	if out.ExitCode != code {
+		t.Errorf("expected exit code %d, got: %d", code, out.ExitCode)
+	}
+}
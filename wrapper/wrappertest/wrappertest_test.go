@@ -0,0 +1,101 @@
+package wrappertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/JayJamieson/cobra-lambda/wrapper"
+	"github.com/spf13/cobra"
+)
+
+// recorder captures failures reported by the helpers instead of failing the test
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertStdoutContains(t *testing.T) {
+	out := &wrapper.CobraLambdaOutput{Stdout: "hello world\n"}
+
+	r := &recorder{TB: t}
+	AssertStdoutContains(r, out, "world")
+	if len(r.failures) != 0 {
+		t.Errorf("Expected no failures, got: %v", r.failures)
+	}
+
+	r = &recorder{TB: t}
+	AssertStdoutContains(r, out, "missing")
+	if len(r.failures) != 1 {
+		t.Errorf("Expected one failure, got: %v", r.failures)
+	}
+
+	r = &recorder{TB: t}
+	AssertStdoutContains(r, nil, "world")
+	if len(r.failures) != 1 {
+		t.Errorf("Expected one failure for nil output, got: %v", r.failures)
+	}
+}
+
+func TestAssertStdoutNotContains(t *testing.T) {
+	out := &wrapper.CobraLambdaOutput{Stdout: "hello world\n"}
+
+	r := &recorder{TB: t}
+	AssertStdoutNotContains(r, out, "missing")
+	if len(r.failures) != 0 {
+		t.Errorf("Expected no failures, got: %v", r.failures)
+	}
+
+	r = &recorder{TB: t}
+	AssertStdoutNotContains(r, out, "world")
+	if len(r.failures) != 1 {
+		t.Errorf("Expected one failure, got: %v", r.failures)
+	}
+}
+
+func TestAssertStdoutEquals(t *testing.T) {
+	out := &wrapper.CobraLambdaOutput{Stdout: "hello\n"}
+
+	r := &recorder{TB: t}
+	AssertStdoutEquals(r, out, "hello\n")
+	if len(r.failures) != 0 {
+		t.Errorf("Expected no failures, got: %v", r.failures)
+	}
+
+	r = &recorder{TB: t}
+	AssertStdoutEquals(r, out, "hello")
+	if len(r.failures) != 1 {
+		t.Errorf("Expected one failure, got: %v", r.failures)
+	}
+}
+
+func TestAssertExitCode(t *testing.T) {
+	cmd := &cobra.Command{
+		Use:           "test",
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return errors.New("failed")
+		},
+	}
+
+	out, _ := wrapper.NewCobraLambdaCLI(context.TODO(), cmd).Execute([]string{})
+
+	r := &recorder{TB: t}
+	AssertExitCode(r, out, 1)
+	if len(r.failures) != 0 {
+		t.Errorf("Expected no failures, got: %v", r.failures)
+	}
+
+	r = &recorder{TB: t}
+	AssertExitCode(r, out, 0)
+	if len(r.failures) != 1 {
+		t.Errorf("Expected one failure, got: %v", r.failures)
+	}
+}